@@ -3,6 +3,10 @@ package binq
 const (
 	Version           = "0.8.1"
 	DefaultBinqServer = "https://binqry.github.io/index/"
-	EnvKeyServer      = "BINQ_SERVER"  // URL of Index Server for install operation
-	EnvKeyBinDir      = "BINQ_BIN_DIR" // Default location to download items
+	EnvKeyServer      = "BINQ_SERVER"      // URL of Index Server for install operation
+	EnvKeyBinDir      = "BINQ_BIN_DIR"     // Default location to download items
+	EnvKeyOffline     = "BINQ_OFFLINE"     // Install items only from caches when set to true
+	EnvKeyRetry       = "BINQ_RETRY"       // Max attempts of HTTP request on transient errors
+	EnvKeyRetryDelay  = "BINQ_RETRY_DELAY" // Initial wait before retry of HTTP request
+	EnvKeyGitHubToken = "GITHUB_TOKEN"     // Token to access github.com and api.github.com
 )
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/binqry/binq/internal/atomicfile"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/xdg"
+)
+
+// ErrNotCached is returned in offline mode when requested data is not found in cache
+var ErrNotCached = errors.New("Not cached for offline use")
+
+const notFoundSuffix = ".notfound"
+
+type fetchFunc func(ctx context.Context, addr string) (*nethttp.Response, error)
+
+// DefaultCacheDir returns the directory to cache Index and Item JSON under $XDG_CACHE_HOME
+func DefaultCacheDir() (dir string) {
+	return filepath.Join(xdg.CacheDir(), "index")
+}
+
+// SetCacheDir sets the directory to cache responses from servers.
+// Cached data is used in offline mode
+func (c *Client) SetCacheDir(dir string) {
+	c.cacheDir = dir
+}
+
+// SetOffline switches offline mode. In offline mode, Client reads data only from cache
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// get sends GET request to addr by fetch and returns status code and response body.
+// Responses of 200 and 404 are cached. In offline mode, cached ones are returned instead
+func (c *Client) get(ctx context.Context, addr string, fetch fetchFunc) (code int, body []byte, err error) {
+	if c.offline {
+		return c.readCache(addr)
+	}
+
+	c.logger.Infof("GET %s", addr)
+	res, _err := fetch(ctx, addr)
+	if _err != nil {
+		return 0, nil, erron.Errorwf(_err, "Failed to execute HTTP request")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		if res.StatusCode == 404 {
+			c.writeCache(addr, nil)
+		}
+		return res.StatusCode, nil, nil
+	}
+	body, _err = ioutil.ReadAll(res.Body)
+	if _err != nil {
+		return res.StatusCode, nil, erron.Errorwf(_err, "Failed to read HTTP response")
+	}
+	c.writeCache(addr, body)
+	return res.StatusCode, body, nil
+}
+
+func (c *Client) readCache(addr string) (code int, body []byte, err error) {
+	if c.cacheDir == "" {
+		return 0, nil, erron.Errorwf(ErrNotCached, "Cache is disabled. URL: %s", addr)
+	}
+	path := c.cachePath(addr)
+	c.logger.Debugf("Read cache of %s: %s", addr, path)
+	body, _err := ioutil.ReadFile(path)
+	if _err == nil {
+		return 200, body, nil
+	}
+	if !os.IsNotExist(_err) {
+		return 0, nil, erron.Errorwf(_err, "Can't read cache file: %s", path)
+	}
+	if _, _err = os.Stat(path + notFoundSuffix); _err == nil {
+		return 404, nil, nil
+	}
+	return 0, nil, erron.Errorwf(ErrNotCached, "Missing in cache: %s", addr)
+}
+
+// writeCache saves body fetched from addr. Nil body means the data is not found on the server.
+// Failure is only logged because cache is not essential in online mode
+func (c *Client) writeCache(addr string, body []byte) {
+	if c.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.cacheDir, 0755); err != nil {
+		c.logger.Warnf("Can't make directory: %s. %v", c.cacheDir, err)
+		return
+	}
+	path := c.cachePath(addr)
+	if body == nil {
+		os.Remove(path)
+		path += notFoundSuffix
+	} else {
+		os.Remove(path + notFoundSuffix)
+	}
+	if err := atomicfile.WriteFile(path, body, 0644); err != nil {
+		c.logger.Warnf("Can't write cache file: %s. %v", path, err)
+		return
+	}
+	c.logger.Debugf("Cached %s: %s", addr, path)
+}
+
+// cachePath returns cache file path of addr. File name is hashed because a path on server
+// can be both a file and a directory
+func (c *Client) cachePath(addr string) (path string) {
+	return filepath.Join(c.cacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(addr))))
+}
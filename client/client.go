@@ -2,14 +2,15 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/url"
 	"strings"
 
 	"github.com/binqry/binq/client/http"
 	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/signature"
 	"github.com/binqry/binq/internal/urls"
 	"github.com/binqry/binq/schema"
 	"github.com/binqry/binq/schema/item"
@@ -21,28 +22,73 @@ var errIndexDataNotFound = errors.New("Index Data is Not Found on given URL")
 type Client struct {
 	ServerURL *url.URL
 	logger    lv.Standard
+	publicKey *signature.PublicKey
+	cacheDir  string
+	offline   bool
+	http      *http.Client
 }
 
-func NewClient(svr *url.URL, logger lv.Standard) (c *Client) {
-	return &Client{ServerURL: svr, logger: logger}
+// NewClient creates Client for the server. opts configures HTTP requests by the client
+func NewClient(svr *url.URL, logger lv.Standard, opts http.Options) (c *Client, err error) {
+	if g, ok := logger.(lv.Granular); ok && opts.Logger == nil {
+		opts.Logger = g
+	}
+	hc, err := http.NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{ServerURL: svr, logger: logger, http: hc}, nil
+}
+
+// SetPublicKey sets trusted public key of the server.
+// Once it is set, signatures of Index and Item JSON are verified; and data without valid
+// signature is rejected.
+func (c *Client) SetPublicKey(key string) (err error) {
+	pk, err := signature.ParsePublicKey(key)
+	if err != nil {
+		return err
+	}
+	c.publicKey = pk
+	return nil
 }
 
 func (c *Client) GetItemInfo(name string) (tgt *item.Item, err error) {
-	tgt, _err := c.GetItemInfoByPath(name)
+	return c.GetItemInfoContext(context.Background(), name)
+}
+
+// GetItemInfoContext is GetItemInfo with ctx to abort requests
+func (c *Client) GetItemInfoContext(ctx context.Context, name string) (tgt *item.Item, err error) {
+	tgt, _, err = c.LookupItemContext(ctx, name)
+	return tgt, err
+}
+
+// LookupItem returns Item data with its path on the index server
+func (c *Client) LookupItem(name string) (tgt *item.Item, pth string, err error) {
+	return c.LookupItemContext(context.Background(), name)
+}
+
+// LookupItemContext is LookupItem with ctx to abort requests
+func (c *Client) LookupItemContext(ctx context.Context, name string) (tgt *item.Item, pth string, err error) {
+	tgt, _err := c.GetItemInfoByPathContext(ctx, name)
 	switch _err {
 	case nil:
 		// OK
 	case errIndexDataNotFound:
 		// Retry
-		return c.getItemInfoByIndex(name)
+		return c.getItemInfoByIndex(ctx, name)
 	default:
-		return tgt, _err
+		return tgt, "", _err
 	}
-	return tgt, nil
+	return tgt, name, nil
 }
 
 func (c *Client) GetIndex() (index *schema.Index, err error) {
-	index, _err := c.getIndex(c.ServerURL.String())
+	return c.GetIndexContext(context.Background())
+}
+
+// GetIndexContext is GetIndex with ctx to abort requests
+func (c *Client) GetIndexContext(ctx context.Context) (index *schema.Index, err error) {
+	index, _err := c.getIndex(ctx, c.ServerURL.String())
 	switch _err {
 	case nil:
 		// OK
@@ -52,7 +98,7 @@ func (c *Client) GetIndex() (index *schema.Index, err error) {
 			// Usually unexpected
 			return nil, erron.Errorwf(_err, "Can't get index data from server: %s", c.ServerURL)
 		}
-		if index, _err = c.getIndex(jsonAddr); _err != nil {
+		if index, _err = c.getIndex(ctx, jsonAddr); _err != nil {
 			msg := fmt.Sprintf("Retry failed. Can't get index data from server: %s", c.ServerURL)
 			return nil, erron.Errorwf(_err, msg)
 		}
@@ -65,38 +111,36 @@ func (c *Client) GetIndex() (index *schema.Index, err error) {
 }
 
 func (c *Client) GetItemInfoByPath(pth string) (tgt *item.Item, err error) {
+	return c.GetItemInfoByPathContext(context.Background(), pth)
+}
+
+// GetItemInfoByPathContext is GetItemInfoByPath with ctx to abort requests
+func (c *Client) GetItemInfoByPathContext(ctx context.Context, pth string) (tgt *item.Item, err error) {
 	addr, _err := urls.Join(c.ServerURL.String(), pth)
 	if _err != nil {
 		// Unexpected case
 		return tgt, erron.Errorwf(_err, "Failed to parse server URL: %v", c.ServerURL)
 	}
 
-	c.logger.Infof("GET %s", addr)
-	res, _err := http.FetchIndex(addr)
-	if _err != nil {
-		err = erron.Errorwf(_err, "Failed to execute HTTP request")
+	code, body, err := c.get(ctx, addr, c.http.FetchIndexContext)
+	if err != nil {
 		return tgt, err
 	}
-	defer res.Body.Close()
-	switch res.StatusCode {
+	switch code {
 	case 200:
 		// OK
 	case 404:
 		c.logger.Debugf("Index Item Data is Not Found: %s", addr)
 		return tgt, errIndexDataNotFound
 	default:
-		err = fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", res.StatusCode, addr)
+		err = fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", code, addr)
 		return tgt, err
 	}
 
-	var b strings.Builder
-	_, _err = io.Copy(&b, res.Body)
-	if _err != nil {
-		err = erron.Errorwf(_err, "Failed to read HTTP response")
+	if err = c.verifySignature(ctx, addr, body); err != nil {
 		return tgt, err
 	}
-
-	tgt, err = item.DecodeItemJSON([]byte(b.String()))
+	tgt, err = item.DecodeItemJSON(body)
 	if err != nil {
 		return tgt, err
 	}
@@ -105,34 +149,26 @@ func (c *Client) GetItemInfoByPath(pth string) (tgt *item.Item, err error) {
 	return tgt, nil
 }
 
-func (c *Client) getIndex(addr string) (index *schema.Index, err error) {
-	c.logger.Infof("GET %s", addr)
-
-	res, _err := http.FetchIndex(addr)
-	if _err != nil {
-		err = erron.Errorwf(_err, "Failed to execute HTTP request")
+func (c *Client) getIndex(ctx context.Context, addr string) (index *schema.Index, err error) {
+	code, body, err := c.get(ctx, addr, c.http.FetchIndexContext)
+	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
-	switch res.StatusCode {
+	switch code {
 	case 200:
 		// OK
 	case 404:
 		c.logger.Debugf("Index Data is Not Found: %s", addr)
 		return nil, errIndexDataNotFound
 	default:
-		err = fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", res.StatusCode, addr)
+		err = fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", code, addr)
 		return nil, err
 	}
 
-	var b strings.Builder
-	_, _err = io.Copy(&b, res.Body)
-	if _err != nil {
-		err = erron.Errorwf(_err, "Failed to read HTTP response")
+	if err = c.verifySignature(ctx, addr, body); err != nil {
 		return index, err
 	}
-
-	index, err = schema.DecodeIndexJSON([]byte(b.String()))
+	index, err = schema.DecodeIndexJSON(body)
 	if err != nil {
 		return index, err
 	}
@@ -141,30 +177,65 @@ func (c *Client) getIndex(addr string) (index *schema.Index, err error) {
 	return index, nil
 }
 
-func (c *Client) getItemInfoByIndex(name string) (tgt *item.Item, err error) {
-	index, err := c.GetIndex()
+func (c *Client) getItemInfoByIndex(ctx context.Context, name string) (tgt *item.Item, pth string, err error) {
+	index, err := c.GetIndexContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	pth := index.FindPath(name)
+	pth = index.FindPath(name)
 	switch pth {
 	case "":
 		err = fmt.Errorf("Can't find item in index: %s", c.ServerURL)
-		return tgt, err
+		return tgt, "", err
 	case name:
 		err = fmt.Errorf(
 			"Found path equals to specified name. Won't retry. name: %s, server: %s", name, c.ServerURL)
-		return tgt, err
+		return tgt, "", err
 	default:
 		// OK
 	}
 
-	tgt, _err := c.GetItemInfoByPath(pth)
+	tgt, _err := c.GetItemInfoByPathContext(ctx, pth)
 	if _err != nil {
 		err = erron.Errorwf(_err, "Failed to get Item Data on path: %s", pth)
-		return tgt, err
+		return tgt, "", err
 	}
 
-	return tgt, nil
+	return tgt, pth, nil
+}
+
+// verifySignature verifies content fetched from addr with the detached signature on the server.
+// It does nothing when public key is not set
+func (c *Client) verifySignature(ctx context.Context, addr string, content []byte) (err error) {
+	if c.publicKey == nil {
+		return nil
+	}
+	sigAddr, err := signatureURL(addr)
+	if err != nil {
+		return err
+	}
+
+	code, sig, err := c.get(ctx, sigAddr, c.http.FetchIndexContext)
+	if err != nil {
+		return err
+	}
+	if code != 200 {
+		return erron.Errorwf(signature.ErrVerificationFailed,
+			"Signature is not available. Code: %d, URL: %s", code, sigAddr)
+	}
+	if _err := c.publicKey.Verify(content, sig); _err != nil {
+		return erron.Errorwf(_err, "Data on the server may be tampered. URL: %s", addr)
+	}
+	c.logger.Debugf("Signature is OK: %s", addr)
+	return nil
+}
+
+// signatureURL returns URL of detached signature for JSON on addr.
+// For a directory like "https://example.com/foo/", it is the one of "index.json" in it.
+func signatureURL(addr string) (sigAddr string, err error) {
+	if strings.HasSuffix(addr, ".json") {
+		return addr + ".sig", nil
+	}
+	return urls.Join(addr, "index.json.sig")
 }
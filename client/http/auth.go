@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/binqry/binq/internal/erron"
+	"github.com/progrhyme/go-lv"
+)
+
+// Credential is used to authenticate requests to Host.
+// Token is sent as Bearer token; otherwise Username and Password are sent by Basic authentication
+type Credential struct {
+	// Host name optionally with port like "github.com" or "artifactory.example.com:8443"
+	Host     string
+	Token    string
+	Username string
+	Password string
+}
+
+// DefaultNetrcPath returns the path of ".netrc" file in home directory
+func DefaultNetrcPath() (path string) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// authTransport sets Authorization header by the credential for the host of each request.
+// Because the header is set on every round trip instead of the original request, credentials
+// are never forwarded to a different host on redirect
+type authTransport struct {
+	base        http.RoundTripper
+	credentials []Credential
+	logger      lv.Granular
+}
+
+func newAuthTransport(base http.RoundTripper, opts Options) (tr http.RoundTripper, err error) {
+	creds := append([]Credential{}, opts.Credentials...)
+	if opts.Netrc != "" {
+		netrc, err := readNetrc(opts.Netrc)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, netrc...)
+	}
+	if len(creds) == 0 {
+		return base, nil
+	}
+	return &authTransport{base: base, credentials: creds, logger: opts.Logger}, nil
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	cred := t.lookup(req.URL.Host, req.URL.Hostname())
+	if cred == nil || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTripper should not modify the given request
+	req = req.Clone(req.Context())
+	if cred.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cred.Token)
+	} else {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+	t.logger.Debugf("Send credential for %s", req.URL.Host)
+	return t.base.RoundTrip(req)
+}
+
+// lookup returns the first credential which matches host with port; or hostname without port
+func (t *authTransport) lookup(host, hostname string) (cred *Credential) {
+	for i, c := range t.credentials {
+		if strings.EqualFold(c.Host, host) {
+			return &t.credentials[i]
+		}
+	}
+	for i, c := range t.credentials {
+		if strings.EqualFold(c.Host, hostname) {
+			return &t.credentials[i]
+		}
+	}
+	return nil
+}
+
+// readNetrc parses .netrc file on path. Missing file is not an error.
+// Only "machine", "login" and "password" tokens are used. "default" entry is ignored so that
+// credentials are not sent to unknown hosts
+func readNetrc(path string) (creds []Credential, err error) {
+	raw, _err := ioutil.ReadFile(path)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return nil, nil
+		}
+		return nil, erron.Errorwf(_err, "Can't read netrc file: %s", path)
+	}
+
+	var cur *Credential
+	flush := func() {
+		if cur != nil && cur.Host != "" {
+			creds = append(creds, *cur)
+		}
+		cur = nil
+	}
+	var tokens []string
+	lines := bufio.NewScanner(bytes.NewReader(raw))
+	for inMacro := false; lines.Scan(); {
+		line := strings.TrimSpace(lines.Text())
+		switch {
+		case inMacro:
+			// Macro definition ends with an empty line
+			inMacro = line != ""
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "macdef"):
+			inMacro = true
+			continue
+		}
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "default":
+			// "default" must be the last entry
+			flush()
+			return creds, nil
+		case "machine", "login", "password", "account":
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			switch tokens[i-1] {
+			case "machine":
+				flush()
+				cur = &Credential{Host: tokens[i]}
+			case "login":
+				if cur != nil {
+					cur.Username = tokens[i]
+				}
+			case "password":
+				if cur != nil {
+					cur.Password = tokens[i]
+				}
+			}
+		}
+	}
+	flush()
+	return creds, nil
+}
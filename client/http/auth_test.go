@@ -0,0 +1,137 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAuth(t *testing.T) {
+	// Server which echoes Authorization header
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}
+	other := httptest.NewServer(http.HandlerFunc(echo))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect/same":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/redirect/other":
+			http.Redirect(w, r, other.URL+"/echo", http.StatusFound)
+		default:
+			echo(w, r)
+		}
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+	otherHost := strings.TrimPrefix(other.URL, "http://")
+
+	tmpdir := t.TempDir()
+	netrc := filepath.Join(tmpdir, ".netrc")
+	content := "# comment\nmachine " + host + "\n  login foo password bar\n" +
+		"macdef init\n  cd /pub\n\ndefault login anonymous password secret\n"
+	if err := ioutil.WriteFile(netrc, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name  string
+		opts  Options
+		path  string
+		index bool
+		want  string
+	}{
+		{name: "no credential", opts: Options{}, path: "/echo", want: ""},
+		{
+			name: "bearer", opts: Options{Credentials: []Credential{{Host: host, Token: "TOKEN"}}},
+			path: "/echo", want: "Bearer TOKEN",
+		},
+		{
+			name: "bearer index", opts: Options{Credentials: []Credential{{Host: host, Token: "TOKEN"}}},
+			path: "/echo", index: true, want: "Bearer TOKEN",
+		},
+		{
+			name: "basic", opts: Options{Credentials: []Credential{{Host: host, Username: "u", Password: "p"}}},
+			path: "/echo", want: "Basic dTpw",
+		},
+		{name: "netrc", opts: Options{Netrc: netrc}, path: "/echo", want: "Basic Zm9vOmJhcg=="},
+		{
+			name: "config over netrc",
+			opts: Options{Credentials: []Credential{{Host: host, Token: "TOKEN"}}, Netrc: netrc},
+			path: "/echo", want: "Bearer TOKEN",
+		},
+		{name: "netrc missing", opts: Options{Netrc: filepath.Join(tmpdir, "none")}, path: "/echo", want: ""},
+		{
+			name: "redirect to same host", opts: Options{Credentials: []Credential{{Host: host, Token: "TOKEN"}}},
+			path: "/redirect/same", want: "Bearer TOKEN",
+		},
+		{
+			name: "redirect to other host", opts: Options{Credentials: []Credential{{Host: host, Token: "TOKEN"}}},
+			path: "/redirect/other", want: "",
+		},
+		{
+			name: "redirect to other host with its credential",
+			opts: Options{Credentials: []Credential{{Host: host, Token: "TOKEN"}, {Host: otherHost, Token: "OTHER"}}},
+			path: "/redirect/other", want: "Bearer OTHER",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewClient(tc.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var res *http.Response
+			if tc.index {
+				res, err = c.FetchIndex(ts.URL + tc.path)
+			} else {
+				res, err = c.Fetch(ts.URL + tc.path)
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer res.Body.Close()
+			body, _ := ioutil.ReadAll(res.Body)
+			if string(body) != tc.want {
+				t.Errorf("Authorization: want %q, got %q", tc.want, string(body))
+			}
+		})
+	}
+}
+
+func TestReadNetrc(t *testing.T) {
+	tmpdir := t.TempDir()
+	netrc := filepath.Join(tmpdir, ".netrc")
+	content := `machine example.com login foo password bar
+machine artifactory.example.com:8443
+  login alice
+  account ignored
+  password s3cret
+macdef init
+  machine evil.example.com login x password y
+
+machine nologin.example.com password only
+default login anonymous password guest
+machine after.default.example.com login a password b
+`
+	if err := ioutil.WriteFile(netrc, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readNetrc(netrc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []Credential{
+		{Host: "example.com", Username: "foo", Password: "bar"},
+		{Host: "artifactory.example.com:8443", Username: "alice", Password: "s3cret"},
+		{Host: "nologin.example.com", Password: "only"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Want %+v, got %+v", want, got)
+	}
+}
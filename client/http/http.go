@@ -1,81 +1,268 @@
-// Package http wraps net/http to suit binq use cases
+// Package http wraps net/http to suit binq use cases.
+// Requests are retried on transient failures according to RetryPolicy.
 package http
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/binqry/binq"
 	"github.com/binqry/binq/internal/erron"
+	"github.com/progrhyme/go-lv"
 )
 
-// Fetch is a shorthand function to execute HTTP GET request primarily to download items.
-func Fetch(addr string) (res *http.Response, err error) {
-	hc := newDefaultClient()
-	req, err := newGetRequest(addr, map[string]string{})
+// Options configures HTTP requests. Zero value of each field means the default
+type Options struct {
+	// Timeout to establish TCP connection. Defaults to 5s
+	DialTimeout time.Duration
+	// Timeout of TLS handshake. Defaults to 5s
+	TLSHandshakeTimeout time.Duration
+	// Timeout to wait for response headers after sending request. No limit by default
+	ResponseHeaderTimeout time.Duration
+	// Total timeout of a request to download files including reading response body. Defaults to 300s
+	Timeout time.Duration
+	// Total timeout of a request to Index Server. Defaults to 5s
+	IndexTimeout time.Duration
+	// Proxy URL which overrides environment variables like HTTPS_PROXY
+	Proxy string
+	// Path to PEM file of CA certificates trusted in addition to system ones
+	CABundle string
+	// Text appended to User-Agent header like "binq/0.8.1 SUFFIX"
+	UserAgentSuffix string
+	// Credentials for hosts. They take precedence over the ones in Netrc
+	Credentials []Credential
+	// Path to .netrc file to read credentials from. Not used when empty
+	Netrc string
+	// Policy to retry requests on transient failures
+	Retry RetryPolicy
+	// Logger to report retries and so on. Nothing is logged when nil
+	Logger lv.Granular
+}
+
+// DefaultOptions holds default values of Options
+var DefaultOptions = Options{
+	DialTimeout:         5 * time.Second,
+	TLSHandshakeTimeout: 5 * time.Second,
+	Timeout:             300 * time.Second,
+	IndexTimeout:        5 * time.Second,
+}
+
+// Client sends HTTP requests configured by Options
+type Client struct {
+	opts     Options
+	download *http.Client
+	index    *http.Client
+}
+
+var defaultClient, _ = NewClient(Options{})
+
+// NewClient creates Client with opts. It fails when proxy URL, CA bundle or netrc file is invalid
+func NewClient(opts Options) (c *Client, err error) {
+	opts = opts.withDefaults()
+	base, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := newAuthTransport(base, opts)
 	if err != nil {
 		return nil, err
 	}
-	return hc.Do(req)
+	return &Client{
+		opts:     opts,
+		download: &http.Client{Transport: tr, Timeout: opts.Timeout},
+		index:    &http.Client{Transport: tr, Timeout: opts.IndexTimeout},
+	}, nil
+}
+
+// Fetch is a shorthand function to execute HTTP GET request primarily to download items.
+// It uses default Options
+func Fetch(addr string) (res *http.Response, err error) {
+	return defaultClient.Fetch(addr)
+}
+
+// FetchContext is a shorthand function of Client.FetchContext with default Options
+func FetchContext(ctx context.Context, addr string) (res *http.Response, err error) {
+	return defaultClient.FetchContext(ctx, addr)
 }
 
 // FetchIndex is a shorthand function to send HTTP GET request to Binq Index Server.
+// It uses default Options
 func FetchIndex(addr string) (res *http.Response, err error) {
-	hc := newClientForIndex()
-	headers := make(map[string]string)
-	headers["Accept"] = "application/json"
-	req, err := newGetRequest(addr, headers)
+	return defaultClient.FetchIndex(addr)
+}
+
+// FetchIndexContext is a shorthand function of Client.FetchIndexContext with default Options
+func FetchIndexContext(ctx context.Context, addr string) (res *http.Response, err error) {
+	return defaultClient.FetchIndexContext(ctx, addr)
+}
+
+// FetchRange is a shorthand function of Client.FetchRange with default Options
+func FetchRange(addr string, offset int64, validator string) (res *http.Response, err error) {
+	return defaultClient.FetchRange(addr, offset, validator)
+}
+
+// FetchRangeContext is a shorthand function of Client.FetchRangeContext with default Options
+func FetchRangeContext(
+	ctx context.Context, addr string, offset int64, validator string,
+) (res *http.Response, err error) {
+	return defaultClient.FetchRangeContext(ctx, addr, offset, validator)
+}
+
+// Fetch executes HTTP GET request primarily to download items.
+func (c *Client) Fetch(addr string) (res *http.Response, err error) {
+	return c.FetchContext(context.Background(), addr)
+}
+
+// FetchContext works like Fetch; but the request and retries are aborted when ctx is done.
+// Reading response body is also aborted then
+func (c *Client) FetchContext(ctx context.Context, addr string) (res *http.Response, err error) {
+	req, err := c.newGetRequest(ctx, addr, map[string]string{})
 	if err != nil {
 		return nil, err
 	}
-	return hc.Do(req)
+	return c.do(c.download, req)
 }
 
-// Functions to create http.Client & http.Request
+// FetchRange works like Fetch; but requests content after offset bytes by Range header when offset
+// is positive. validator is sent in If-Range header so that the server returns full content when
+// the content has changed.
+func (c *Client) FetchRange(addr string, offset int64, validator string) (res *http.Response, err error) {
+	return c.FetchRangeContext(context.Background(), addr, offset, validator)
+}
 
-var (
-	// defaultTimeout represents default timeout period of HTTP requests.
-	defaultTimeout      time.Duration = 300 * time.Second
-	timeoutToQueryIndex time.Duration = 5 * time.Second
-)
+// FetchRangeContext is FetchRange with ctx to abort the request
+func (c *Client) FetchRangeContext(
+	ctx context.Context, addr string, offset int64, validator string,
+) (res *http.Response, err error) {
+	headers := make(map[string]string)
+	if offset > 0 {
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+		if validator != "" {
+			headers["If-Range"] = validator
+		}
+	}
+	req, err := c.newGetRequest(ctx, addr, headers)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(c.download, req)
+}
 
-const undefinedHTTPTimeout = -1
+// FetchIndex sends HTTP GET request to Binq Index Server.
+func (c *Client) FetchIndex(addr string) (res *http.Response, err error) {
+	return c.FetchIndexContext(context.Background(), addr)
+}
 
-func newClient(timeout time.Duration) (hc *http.Client) {
-	if timeout == undefinedHTTPTimeout {
-		timeout = defaultTimeout
+// FetchIndexContext is FetchIndex with ctx to abort the request
+func (c *Client) FetchIndexContext(ctx context.Context, addr string) (res *http.Response, err error) {
+	headers := make(map[string]string)
+	headers["Accept"] = "application/json"
+	req, err := c.newGetRequest(ctx, addr, headers)
+	if err != nil {
+		return nil, err
 	}
-	hc = &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout: 5 * time.Second,
-			}).DialContext,
-			IdleConnTimeout:       10 * time.Second,
-			TLSHandshakeTimeout:   5 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		},
-		Timeout: timeout,
+	return c.do(c.index, req)
+}
+
+// Validator returns ETag or Last-Modified header of res which can be used in If-Range header.
+// Weak ETag is ignored because it is not allowed in If-Range
+func Validator(res *http.Response) (validator string) {
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
 	}
-	return hc
+	return res.Header.Get("Last-Modified")
 }
 
-func newDefaultClient() (hc *http.Client) {
-	return newClient(defaultTimeout)
+// RangeStart returns the first byte position in Content-Range header of partial response.
+// It returns -1 when the header is missing or malformed
+func RangeStart(res *http.Response) (start int64) {
+	var end int64
+	if _, err := fmt.Sscanf(res.Header.Get("Content-Range"), "bytes %d-%d/", &start, &end); err != nil {
+		return -1
+	}
+	return start
+}
+
+// Functions to create http.Transport & http.Request
+
+func (opts Options) withDefaults() Options {
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = DefaultOptions.DialTimeout
+	}
+	if opts.TLSHandshakeTimeout == 0 {
+		opts.TLSHandshakeTimeout = DefaultOptions.TLSHandshakeTimeout
+	}
+	if opts.ResponseHeaderTimeout == 0 {
+		opts.ResponseHeaderTimeout = DefaultOptions.ResponseHeaderTimeout
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultOptions.Timeout
+	}
+	if opts.IndexTimeout == 0 {
+		opts.IndexTimeout = DefaultOptions.IndexTimeout
+	}
+	opts.Retry = opts.Retry.withDefaults()
+	if opts.Logger == nil {
+		opts.Logger = lv.New(ioutil.Discard, lv.LError, 0)
+	}
+	return opts
 }
 
-func newClientForIndex() (hc *http.Client) {
-	return newClient(timeoutToQueryIndex)
+func newTransport(opts Options) (tr *http.Transport, err error) {
+	tr = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: opts.DialTimeout,
+		}).DialContext,
+		IdleConnTimeout:       10 * time.Second,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if opts.Proxy != "" {
+		proxy, _err := url.Parse(opts.Proxy)
+		if _err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy URL: %s", opts.Proxy)
+		}
+		tr.Proxy = http.ProxyURL(proxy)
+	}
+	if opts.CABundle != "" {
+		pem, _err := ioutil.ReadFile(opts.CABundle)
+		if _err != nil {
+			return nil, erron.Errorwf(_err, "Can't read CA bundle: %s", opts.CABundle)
+		}
+		pool, _err := x509.SystemCertPool()
+		if _err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificate is found in CA bundle: %s", opts.CABundle)
+		}
+		tr.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return tr, nil
 }
 
-func newGetRequest(url string, headers map[string]string) (req *http.Request, err error) {
-	req, _err := http.NewRequest(http.MethodGet, url, nil)
+func (c *Client) newGetRequest(
+	ctx context.Context, url string, headers map[string]string,
+) (req *http.Request, err error) {
+	req, _err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if _err != nil {
 		return req, erron.Errorwf(_err, "Failed to create HTTP request")
 	}
-	req.Header.Set("User-Agent", fmt.Sprintf("binq/%s", binq.Version))
+	ua := fmt.Sprintf("binq/%s", binq.Version)
+	if c.opts.UserAgentSuffix != "" {
+		ua = fmt.Sprintf("%s %s", ua, c.opts.UserAgentSuffix)
+	}
+	req.Header.Set("User-Agent", ua)
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
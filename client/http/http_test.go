@@ -0,0 +1,140 @@
+package http
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/binqry/binq"
+)
+
+func TestNewClient(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.UserAgent()))
+	}))
+	defer ts.Close()
+
+	tmpdir := t.TempDir()
+	caBundle := filepath.Join(tmpdir, "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := ioutil.WriteFile(caBundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	noCert := filepath.Join(tmpdir, "empty.pem")
+	if err := ioutil.WriteFile(noCert, []byte("foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		opts      Options
+		wantError bool
+		wantUA    string
+		fetchFail bool
+	}{
+		{name: "default", opts: Options{}, fetchFail: true},
+		{name: "ca bundle", opts: Options{CABundle: caBundle}, wantUA: "binq/" + binq.Version},
+		{
+			name:   "ua suffix",
+			opts:   Options{CABundle: caBundle, UserAgentSuffix: "corp-ci"},
+			wantUA: "binq/" + binq.Version + " corp-ci",
+		},
+		{name: "missing ca bundle", opts: Options{CABundle: filepath.Join(tmpdir, "none.pem")}, wantError: true},
+		{name: "ca bundle without cert", opts: Options{CABundle: noCert}, wantError: true},
+		{name: "invalid proxy", opts: Options{Proxy: "://proxy"}, wantError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Retry = RetryPolicy{MaxAttempts: 1}
+			c, err := NewClient(tc.opts)
+			if tc.wantError {
+				if err == nil {
+					t.Errorf("Expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			res, err := c.Fetch(ts.URL)
+			if tc.fetchFail {
+				if err == nil {
+					res.Body.Close()
+					t.Errorf("Expected certificate error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer res.Body.Close()
+			body, _ := ioutil.ReadAll(res.Body)
+			if string(body) != tc.wantUA {
+				t.Errorf("User-Agent: want %q, got %q", tc.wantUA, string(body))
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(Options{
+		Timeout: 50 * time.Millisecond, IndexTimeout: time.Second, Retry: RetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Fetch(ts.URL); err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("Expected timeout error but got %v", err)
+	}
+	res, err := c.FetchIndex(ts.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+}
+
+func TestFetchContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewClient(Options{Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/slow", "/unavailable"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		res, err := c.FetchContext(ctx, ts.URL+path)
+		cancel()
+		if err == nil {
+			res.Body.Close()
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("[%s] Expected deadline error but got %v", path, err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("[%s] Request is not aborted. Elapsed: %s", path, elapsed)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"io"
+	"net/http"
+)
+
+// ProgressFunc receives progress of reading response body. read and total include offset of
+// resumed download; and total is -1 when Content-Length is unknown. done is true when reading
+// is finished by EOF or error
+type ProgressFunc func(read, total int64, done bool)
+
+type progressReader struct {
+	io.ReadCloser
+	read, total int64
+	report      ProgressFunc
+	finished    bool
+}
+
+// WithProgress wraps body of res so that fn is called on every read of it. fn is also called once
+// before reading with read = offset, which is the number of bytes downloaded before and should be
+// given for partial response. It does nothing when fn is nil
+func WithProgress(res *http.Response, offset int64, fn ProgressFunc) {
+	if fn == nil {
+		return
+	}
+	total := int64(-1)
+	if res.ContentLength >= 0 {
+		total = offset + res.ContentLength
+	}
+	res.Body = &progressReader{ReadCloser: res.Body, read: offset, total: total, report: fn}
+	fn(offset, total, false)
+}
+
+func (p *progressReader) Read(b []byte) (n int, err error) {
+	n, err = p.ReadCloser.Read(b)
+	p.read += int64(n)
+	if p.finished {
+		return n, err
+	}
+	p.finished = err != nil
+	p.report(p.read, p.total, p.finished)
+	return n, err
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/binqry/binq/internal/erron"
+)
+
+// RetryPolicy defines how failed requests are retried.
+// Only GET and HEAD requests are retried; and only on transient failures: network errors like
+// connection reset or timeout, 429 and 5xx responses except for 501 and 505.
+type RetryPolicy struct {
+	// Maximum number of attempts including the first one. 1 means no retry
+	MaxAttempts int
+	// Wait before the first retry. It doubles on each retry; and is randomized by jitter
+	BaseDelay time.Duration
+	// Upper limit of wait. When Retry-After header requires longer wait, the request is not retried
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy holds default values of RetryPolicy. Zero value of each field of RetryPolicy
+// in Options means the default
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 1 * time.Second, MaxDelay: 30 * time.Second}
+
+var (
+	jitter   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	jitterMu sync.Mutex
+)
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+// do sends request by hc retrying it according to retry policy of c
+func (c *Client) do(hc *http.Client, req *http.Request) (res *http.Response, err error) {
+	p, logger := c.opts.Retry, c.opts.Logger
+	for attempt := 1; ; attempt++ {
+		res, err = hc.Do(req)
+		if attempt >= p.MaxAttempts || req.Context().Err() != nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+			return res, err
+		}
+		reason, ok := retryable(res, err)
+		if !ok {
+			return res, err
+		}
+		wait := p.backoff(attempt)
+		if res != nil {
+			if after, ok := retryAfter(res); ok {
+				if after > p.MaxDelay {
+					logger.Debugf("Give up retry. Retry-After: %s, URL: %s", after, req.URL)
+					return res, err
+				}
+				wait = after
+			}
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		logger.Noticef("%s. Retry in %s (%d/%d). URL: %s", reason, wait, attempt, p.MaxAttempts-1, req.URL)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, erron.Errorwf(req.Context().Err(), "Retry is canceled. URL: %s", req.URL)
+		}
+	}
+}
+
+// backoff returns wait before the retry after attempt-th request.
+// It is randomized between a half and the whole of exponential backoff
+func (p RetryPolicy) backoff(attempt int) (wait time.Duration) {
+	wait = p.BaseDelay
+	for i := 1; i < attempt && wait < p.MaxDelay; i++ {
+		wait *= 2
+	}
+	if wait > p.MaxDelay {
+		wait = p.MaxDelay
+	}
+	if wait <= 1 {
+		return wait
+	}
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return wait/2 + time.Duration(jitter.Int63n(int64(wait/2)))
+}
+
+// retryable reports whether the request should be retried with the reason
+func retryable(res *http.Response, err error) (reason string, ok bool) {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return "", false
+		}
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return fmt.Sprintf("DNS lookup failed. %v", err), dnsErr.IsTemporary || dnsErr.IsTimeout
+		}
+		var netErr net.Error
+		switch {
+		case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED),
+			errors.Is(err, syscall.ECONNABORTED), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return fmt.Sprintf("Connection failed. %v", err), true
+		case errors.As(err, &netErr) && netErr.Timeout():
+			return fmt.Sprintf("Request timed out. %v", err), true
+		}
+		return "", false
+	}
+
+	switch code := res.StatusCode; {
+	case code == http.StatusTooManyRequests,
+		code >= 500 && code != http.StatusNotImplemented && code != http.StatusHTTPVersionNotSupported:
+		return fmt.Sprintf("Server responded %s", res.Status), true
+	}
+	return "", false
+}
+
+// retryAfter parses Retry-After header in seconds or HTTP date
+func retryAfter(res *http.Response) (wait time.Duration, ok bool) {
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if wait = time.Until(t); wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newFlakyServer returns a server which fails n times with given failure; and then responds 200.
+// Requests are counted in *count
+func newFlakyServer(n int, fail func(w http.ResponseWriter), count *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*count++
+		if *count <= n {
+			fail(w)
+			return
+		}
+		fmt.Fprint(w, "OK")
+	}))
+}
+
+func TestRetry(t *testing.T) {
+	status := func(code int) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) { w.WriteHeader(code) }
+	}
+	retryAfter := func(after string) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", after)
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}
+	reset := func(w http.ResponseWriter) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}
+
+	testCases := []struct {
+		name        string
+		fails       int
+		fail        func(w http.ResponseWriter)
+		maxAttempts int
+		wantCode    int
+		wantCount   int
+	}{
+		{name: "503", fails: 2, fail: status(503), maxAttempts: 3, wantCode: 200, wantCount: 3},
+		{name: "502 exceeds", fails: 3, fail: status(502), maxAttempts: 3, wantCode: 502, wantCount: 3},
+		{name: "no retry", fails: 1, fail: status(503), maxAttempts: 1, wantCode: 503, wantCount: 1},
+		{name: "404", fails: 1, fail: status(404), maxAttempts: 3, wantCode: 404, wantCount: 1},
+		{name: "501", fails: 1, fail: status(501), maxAttempts: 3, wantCode: 501, wantCount: 1},
+		{name: "429", fails: 1, fail: retryAfter("0"), maxAttempts: 3, wantCode: 200, wantCount: 2},
+		{name: "429 too long", fails: 1, fail: retryAfter("60"), maxAttempts: 3, wantCode: 429, wantCount: 1},
+		{name: "connection reset", fails: 2, fail: reset, maxAttempts: 3, wantCode: 200, wantCount: 3},
+		{name: "connection reset exceeds", fails: 2, fail: reset, maxAttempts: 2, wantCode: 0, wantCount: 2},
+	}
+	for _, tc := range testCases {
+		c, err := NewClient(Options{Retry: RetryPolicy{
+			MaxAttempts: tc.maxAttempts, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var count int
+		ts := newFlakyServer(tc.fails, tc.fail, &count)
+		for _, fetch := range []func(string) (*http.Response, error){c.Fetch, c.FetchIndex} {
+			count = 0
+			res, err := fetch(ts.URL)
+			var code int
+			if err == nil {
+				code = res.StatusCode
+				res.Body.Close()
+			}
+			if code != tc.wantCode {
+				t.Errorf("[%s] Status code mismatch. Want: %d, Got: %d, Error: %v", tc.name, tc.wantCode, code, err)
+			}
+			if count != tc.wantCount {
+				t.Errorf("[%s] Attempts mismatch. Want: %d, Got: %d", tc.name, tc.wantCount, count)
+			}
+		}
+		ts.Close()
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max := want * time.Millisecond
+		for i := 0; i < 10; i++ {
+			if got := p.backoff(attempt + 1); got < max/2 || got > max {
+				t.Errorf("Backoff out of range. Attempt: %d, Want: %s-%s, Got: %s", attempt+1, max/2, max, got)
+			}
+		}
+	}
+}
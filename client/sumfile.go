@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/schema/item"
+)
+
+// FetchChecksums downloads checksum file published by upstream and parses it.
+// Algorithm is guessed by the file name; or by the length of checksums.
+// defaultFile is used for the file which has only a checksum like "foo.zip.sha256".
+func FetchChecksums(addr, defaultFile string) (sums []item.ItemChecksum, err error) {
+	return FetchChecksumsContext(context.Background(), addr, defaultFile)
+}
+
+// FetchChecksumsContext is FetchChecksums with ctx to abort the request
+func FetchChecksumsContext(ctx context.Context, addr, defaultFile string) (sums []item.ItemChecksum, err error) {
+	res, _err := http.FetchContext(ctx, addr)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to execute HTTP request")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", res.StatusCode, addr)
+	}
+
+	b, _err := ioutil.ReadAll(res.Body)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to read HTTP response")
+	}
+	return parseChecksums(addr, b, defaultFile)
+}
+
+// GetChecksums works like FetchChecksums; but caches the checksum file and reads the cached
+// one in offline mode
+func (c *Client) GetChecksums(addr, defaultFile string) (sums []item.ItemChecksum, err error) {
+	return c.GetChecksumsContext(context.Background(), addr, defaultFile)
+}
+
+// GetChecksumsContext is GetChecksums with ctx to abort the request
+func (c *Client) GetChecksumsContext(
+	ctx context.Context, addr, defaultFile string,
+) (sums []item.ItemChecksum, err error) {
+	b, err := c.GetFileContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return parseChecksums(addr, b, defaultFile)
+}
+
+// GetFile downloads small file like checksum file or signature on addr. The file is cached and
+// the cached one is read in offline mode
+func (c *Client) GetFile(addr string) (content []byte, err error) {
+	return c.GetFileContext(context.Background(), addr)
+}
+
+// GetFileContext is GetFile with ctx to abort the request
+func (c *Client) GetFileContext(ctx context.Context, addr string) (content []byte, err error) {
+	code, content, err := c.get(ctx, addr, c.http.FetchContext)
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", code, addr)
+	}
+	return content, nil
+}
+
+func parseChecksums(addr string, content []byte, defaultFile string) (sums []item.ItemChecksum, err error) {
+	uri, _err := url.Parse(addr)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to parse checksum URL: %s", addr)
+	}
+	sums, _err = item.ParseSumFile(content, item.GuessChecksumType(uri.Path), defaultFile)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to parse checksum file: %s", addr)
+	}
+	return sums, nil
+}
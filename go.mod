@@ -1,6 +1,6 @@
 module github.com/binqry/binq
 
-go 1.14
+go 1.17
 
 require (
 	github.com/google/go-cmp v0.5.0
@@ -11,4 +11,19 @@ require (
 	github.com/pmezard/go-difflib v1.0.0
 	github.com/progrhyme/go-lv v0.4.1
 	github.com/spf13/pflag v1.0.5
+	golang.org/x/crypto v0.11.0
+)
+
+require (
+	github.com/andybalholm/brotli v0.0.0-20190621154722-5f990b63d2d6 // indirect
+	github.com/dsnet/compress v0.0.1 // indirect
+	github.com/golang/gddo v0.0.0-20190419222130-af0f2af80721 // indirect
+	github.com/golang/snappy v0.0.1 // indirect
+	github.com/klauspost/compress v1.9.2 // indirect
+	github.com/klauspost/pgzip v1.2.1 // indirect
+	github.com/nwaples/rardecode v1.0.0 // indirect
+	github.com/pierrec/lz4 v2.0.5+incompatible // indirect
+	github.com/ulikunitz/xz v0.5.6 // indirect
+	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8 // indirect
+	golang.org/x/sys v0.10.0 // indirect
 )
@@ -0,0 +1,169 @@
+package install
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/binqry/binq/internal/config"
+	"github.com/binqry/binq/internal/xdg"
+	"github.com/binqry/binq/schema/item"
+)
+
+// AuxRole is the role of auxiliary file shipped with executables in archive. Auxiliary files
+// are declared by "aux-files" in Item Manifest
+type AuxRole string
+
+const (
+	AuxBashCompletion AuxRole = "bash-completion"
+	AuxZshCompletion  AuxRole = "zsh-completion"
+	AuxFishCompletion AuxRole = "fish-completion"
+	AuxMan            AuxRole = "man"
+	AuxLicense        AuxRole = "license"
+)
+
+// auxRoles lists supported roles in order of matching
+var auxRoles = []AuxRole{AuxBashCompletion, AuxZshCompletion, AuxFishCompletion, AuxMan, AuxLicense}
+
+// DefaultAuxDirs returns default directories to install auxiliary files under $XDG_DATA_HOME.
+// Man pages are placed into "manN" subdirectory for their sections; and licenses into the
+// subdirectory named after the item
+func DefaultAuxDirs() (dirs map[AuxRole]string) {
+	data := xdg.DataHome()
+	return map[AuxRole]string{
+		AuxBashCompletion: filepath.Join(data, "bash-completion", "completions"),
+		AuxZshCompletion:  filepath.Join(data, "zsh", "site-functions"),
+		AuxFishCompletion: filepath.Join(data, "fish", "vendor_completions.d"),
+		AuxMan:            filepath.Join(data, "man"),
+		AuxLicense:        DefaultShareDir(),
+	}
+}
+
+// auxSelector finds auxiliary files in extracted archive
+type auxSelector struct {
+	patterns map[AuxRole]string
+}
+
+// newAuxSelector returns auxSelector by "aux-files" of the item. Unknown roles are ignored
+func (r *Runner) newAuxSelector() (aux *auxSelector, err error) {
+	aux = &auxSelector{patterns: make(map[AuxRole]string)}
+	if r.sourceItem == nil {
+		return aux, nil
+	}
+	patterns, err := r.sourceItem.GetAuxFilePatterns(item.FormatParam{OS: r.os, Arch: r.arch})
+	if err != nil {
+		return nil, err
+	}
+	for role, p := range patterns {
+		if !isAuxRole(AuxRole(role)) {
+			r.Logger.Warnf("Unknown role of aux-files: %s", role)
+			continue
+		}
+		if err = validatePatterns([]string{p}); err != nil {
+			return nil, err
+		}
+		aux.patterns[AuxRole(role)] = p
+	}
+	return aux, nil
+}
+
+// match returns the role of the file at rel. rel is slash-separated path from the root of archive
+func (aux *auxSelector) match(rel string) (role AuxRole, ok bool) {
+	for _, role := range auxRoles {
+		if p, ok := aux.patterns[role]; ok && matchFile(p, rel) {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// sorted returns paths of files in order of roles and paths
+func (aux *auxSelector) sorted(files map[string]AuxRole) (paths []string) {
+	for path := range files {
+		paths = append(paths, path)
+	}
+	order := make(map[AuxRole]int)
+	for i, role := range auxRoles {
+		order[role] = i
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		ri, rj := order[files[paths[i]]], order[files[paths[j]]]
+		if ri != rj {
+			return ri < rj
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// auxDest returns the destination of auxiliary file at path. It returns empty string when the
+// file can't be installed
+func (r *Runner) auxDest(role AuxRole, path string) (dest string, err error) {
+	dir, err := r.auxDir(role)
+	if err != nil {
+		return "", err
+	}
+	name := r.destName(path)
+	switch role {
+	case AuxMan:
+		section, ok := manSection(name)
+		if !ok {
+			r.Logger.Warnf("Section of man page is unknown. Skip %s", name)
+			return "", nil
+		}
+		return filepath.Join(dir, "man"+section, name), nil
+	case AuxLicense:
+		return filepath.Join(dir, r.shareName(), name), nil
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// auxDir returns the directory to install auxiliary files of role. AuxDirs of the Runner takes
+// precedence over "dirs" in configuration file; and they over DefaultAuxDirs
+func (r *Runner) auxDir(role AuxRole) (dir string, err error) {
+	if dir = r.AuxDirs[role]; dir != "" {
+		return dir, nil
+	}
+	if r.ConfigPath != "" {
+		cfg, err := config.Load(r.ConfigPath)
+		if err != nil {
+			return "", err
+		}
+		if dir = cfg.Dirs[string(role)]; dir != "" {
+			return dir, nil
+		}
+	}
+	if role == AuxLicense {
+		return r.ShareDir, nil
+	}
+	return DefaultAuxDirs()[role], nil
+}
+
+func isAuxRole(role AuxRole) bool {
+	for _, r := range auxRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// manSection returns section number of man page by its file name like "tool.1" or "tool.8.gz"
+func manSection(name string) (section string, ok bool) {
+	ext := filepath.Ext(strings.TrimSuffix(name, ".gz"))
+	if len(ext) < 2 || ext[1] < '1' || ext[1] > '9' {
+		return "", false
+	}
+	return ext[1:2], true
+}
+
+// validateAuxDirs returns error when dirs has unknown roles
+func validateAuxDirs(dirs map[AuxRole]string) (err error) {
+	for role := range dirs {
+		if !isAuxRole(role) {
+			return fmt.Errorf("Unknown role of auxiliary files: %s", role)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/progrhyme/go-lv"
+)
+
+const testAuxItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/download/pkg.zip",
+    "aux-files": {
+      "bash-completion": "*/completions/*.bash",
+      "zsh-completion": "_tool",
+      "fish-completion": "*.fish",
+      "man": "*/man/*",
+      "license": "LICENSE"
+    }
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0"
+    }
+  ]
+}`
+
+func TestAuxFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Executable bits are not used on Windows")
+	}
+	archive := newTestArchive(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/pkg", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, testAuxItemJSONFormat, r.Host)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	cfgPath := filepath.Join(tmpdir, "config.json")
+	fishDir := filepath.Join(tmpdir, "fish")
+	cfg := fmt.Sprintf(`{"dirs": {"fish-completion": %q, "man": "/must/not/be/used"}}`, fishDir)
+	if err := ioutil.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+		t.Fatalf("Failed to write config. %v", err)
+	}
+	binDir := filepath.Join(tmpdir, "bin")
+	os.Mkdir(binDir, 0755)
+	regPath := filepath.Join(tmpdir, "installed.json")
+	log := &strings.Builder{}
+	err := Run(RunOption{
+		Source:       "pkg",
+		DestDir:      binDir,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		ServerURL:    ts.URL,
+		RegistryPath: regPath,
+		ConfigPath:   cfgPath,
+		ShareDir:     filepath.Join(tmpdir, "share"),
+		AuxDirs: map[AuxRole]string{
+			AuxBashCompletion: filepath.Join(tmpdir, "bash"),
+			AuxZshCompletion:  filepath.Join(tmpdir, "zsh"),
+			AuxMan:            filepath.Join(tmpdir, "man"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+
+	want := []string{
+		filepath.Join(binDir, "tool"),
+		filepath.Join(tmpdir, "bash", "tool.bash"),
+		filepath.Join(tmpdir, "zsh", "_tool"),
+		filepath.Join(fishDir, "tool.fish"),
+		filepath.Join(tmpdir, "man", "man1", "tool.1"),
+		filepath.Join(tmpdir, "share", "pkg", "LICENSE"),
+	}
+	for _, f := range want {
+		if _, err = os.Stat(f); err != nil {
+			t.Errorf("Installed file not found: %s. %v", f, err)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(binDir, "README")); !os.IsNotExist(err) {
+		t.Errorf("Non-executable file should not be installed into bin directory")
+	}
+
+	if _, err = Uninstall(UninstallOption{
+		Name: "pkg", Output: log, LogLevel: lv.LNotice, RegistryPath: regPath,
+	}); err != nil {
+		t.Fatalf("Uninstall failed. %v\nLog: %s", err, log)
+	}
+	for _, f := range want {
+		if _, err = os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("File remains after uninstall: %s", f)
+		}
+	}
+
+	if err = Run(RunOption{Source: "pkg", AuxDirs: map[AuxRole]string{"no-such-role": tmpdir}}); err == nil {
+		t.Errorf("Unknown role of AuxDirs should be an error")
+	}
+}
@@ -0,0 +1,388 @@
+// Package cache implements persistent cache of files downloaded by binq.
+// Files are stored by their checksums; and indexed with their source URLs.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binqry/binq/internal/atomicfile"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/xdg"
+	"github.com/binqry/binq/schema/item"
+)
+
+const (
+	indexFileName = "index.json"
+	blobDirName   = "blobs"
+)
+
+// indexMu serializes updates of cache index in the process
+var indexMu sync.Mutex
+
+// Cache wraps cacheProps which corresponds to JSON structure of cache index
+type Cache struct {
+	*cacheProps
+	dir string
+}
+
+type cacheProps struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Entry represents a cached file
+type Entry struct {
+	URL string `json:"url"`
+	// Base name of the file
+	File       string            `json:"file"`
+	Checksum   item.ItemChecksum `json:"checksum"`
+	Size       int64             `json:"size"`
+	CreatedAt  time.Time         `json:"created-at"`
+	AccessedAt time.Time         `json:"accessed-at"`
+}
+
+// DefaultDir returns the cache directory under $XDG_CACHE_HOME
+func DefaultDir() (dir string) {
+	return filepath.Join(xdg.CacheDir(), "downloads")
+}
+
+// Open loads cache index in dir. It returns empty Cache when the index does not exist
+func Open(dir string) (c *Cache, err error) {
+	c = &Cache{cacheProps: &cacheProps{Entries: []Entry{}}, dir: dir}
+	path := c.indexPath()
+	raw, _err := ioutil.ReadFile(path)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return c, nil
+		}
+		return nil, erron.Errorwf(_err, "Can't read cache index: %s", path)
+	}
+	if _err = json.Unmarshal(raw, c.cacheProps); _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to unmarshal JSON: %s", path)
+	}
+	return c, nil
+}
+
+func (c *Cache) String() string {
+	return fmt.Sprintf("%+v", *c.cacheProps)
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() (dir string) {
+	return c.dir
+}
+
+// Save writes cache index into its file
+func (c *Cache) Save() (err error) {
+	b, _err := c.ToJSON(true)
+	if _err != nil {
+		return _err
+	}
+	if _err = os.MkdirAll(c.dir, 0755); _err != nil {
+		return erron.Errorwf(_err, "Can't make directory: %s", c.dir)
+	}
+	// Open without lock must never read partial index
+	path := c.indexPath()
+	if _err = atomicfile.WriteFile(path, append(b, '\n'), 0644); _err != nil {
+		return erron.Errorwf(_err, "Can't write cache index: %s", path)
+	}
+	return nil
+}
+
+func (c *Cache) ToJSON(pretty bool) (b []byte, err error) {
+	var _err error
+	if pretty {
+		b, _err = json.MarshalIndent(c.cacheProps, "", "  ")
+	} else {
+		b, _err = json.Marshal(c.cacheProps)
+	}
+	if _err != nil {
+		return b, erron.Errorwf(_err, "Failed to marshal JSON: %s", c)
+	}
+	return b, nil
+}
+
+// Lookup returns cached entry whose checksum matches any of the ones in cs.
+// The entry for the same URL is preferred. It returns nil when no entry is found
+func (c *Cache) Lookup(url string, cs *item.ItemChecksum) (entry *Entry) {
+	var found *Entry
+	for i, e := range c.Entries {
+		if !matchAny(&e.Checksum, cs) {
+			continue
+		}
+		if _, err := os.Stat(c.BlobPath(e)); err != nil {
+			continue
+		}
+		if e.URL == url {
+			return &c.Entries[i]
+		}
+		if found == nil {
+			found = &c.Entries[i]
+		}
+	}
+	return found
+}
+
+// LookupURL returns the most recently used entry for url. It returns nil when no entry is found
+func (c *Cache) LookupURL(url string) (entry *Entry) {
+	for i, e := range c.Entries {
+		if e.URL != url {
+			continue
+		}
+		if _, err := os.Stat(c.BlobPath(e)); err != nil {
+			continue
+		}
+		if entry == nil || e.AccessedAt.After(entry.AccessedAt) {
+			entry = &c.Entries[i]
+		}
+	}
+	return entry
+}
+
+// Open opens cached file of entry
+func (c *Cache) Open(entry Entry) (f *os.File, err error) {
+	f, _err := os.Open(c.BlobPath(entry))
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Can't open cached file: %s", c.BlobPath(entry))
+	}
+	return f, nil
+}
+
+// Touch updates last access time of entry and saves cache index
+func (c *Cache) Touch(entry *Entry) (err error) {
+	now := time.Now()
+	entry.AccessedAt = now
+	return c.update(func() error {
+		for i, e := range c.Entries {
+			if e.URL == entry.URL && c.BlobPath(e) == c.BlobPath(*entry) {
+				c.Entries[i].AccessedAt = now
+			}
+		}
+		return nil
+	})
+}
+
+// Put stores file downloaded from url with its checksum sum into cache; and saves cache index
+func (c *Cache) Put(url, file string, sum *item.ItemChecksum) (entry *Entry, err error) {
+	s, _, t := sum.GetSumAndHasher()
+	if s == "" {
+		return nil, fmt.Errorf("No checksum to store file in cache: %s", file)
+	}
+	now := time.Now()
+	e := Entry{
+		URL:        url,
+		File:       filepath.Base(file),
+		Checksum:   *sum,
+		CreatedAt:  now,
+		AccessedAt: now,
+	}
+	e.Checksum.File = e.File
+
+	blob := c.BlobPath(e)
+	if err = copyFile(file, blob); err != nil {
+		return nil, err
+	}
+	fi, _err := os.Stat(blob)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to get file info: %s", blob)
+	}
+	e.Size = fi.Size()
+
+	err = c.update(func() error {
+		c.remove(func(old Entry) bool {
+			return old.URL == url && old.Checksum.GetSum(t) == s
+		})
+		c.Entries = append(c.Entries, e)
+		sort.SliceStable(c.Entries, func(i, j int) bool { return c.Entries[i].URL < c.Entries[j].URL })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Remove deletes entry and its file from cache; and saves cache index
+func (c *Cache) Remove(entry Entry) (err error) {
+	return c.update(func() error {
+		c.remove(func(e Entry) bool {
+			return e.URL == entry.URL && c.BlobPath(e) == c.BlobPath(entry)
+		})
+		return c.removeBlob(entry)
+	})
+}
+
+// Prune removes entries which are not accessed within maxAge; and then removes least recently
+// used entries until total size gets equal to or less than maxSize.
+// Zero value of maxAge or maxSize means no limit.
+func (c *Cache) Prune(maxAge time.Duration, maxSize int64) (removed []Entry, err error) {
+	err = c.update(func() (err error) {
+		removed, err = c.prune(maxAge, maxSize)
+		return err
+	})
+	return removed, err
+}
+
+func (c *Cache) prune(maxAge time.Duration, maxSize int64) (removed []Entry, err error) {
+	entries := append([]Entry{}, c.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].AccessedAt.Before(entries[j].AccessedAt)
+	})
+
+	total := c.TotalSize()
+	now := time.Now()
+	for _, e := range entries {
+		expired := maxAge > 0 && now.Sub(e.AccessedAt) > maxAge
+		oversize := maxSize > 0 && total > maxSize
+		if !expired && !oversize {
+			continue
+		}
+		c.remove(func(x Entry) bool { return x.URL == e.URL && c.BlobPath(x) == c.BlobPath(e) })
+		if err = c.removeBlob(e); err != nil {
+			return removed, err
+		}
+		total -= e.Size
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// Clean removes all cached files and cache index
+func (c *Cache) Clean() (err error) {
+	if _err := os.RemoveAll(c.dir); _err != nil {
+		return erron.Errorwf(_err, "Failed to remove cache directory: %s", c.dir)
+	}
+	c.Entries = []Entry{}
+	return nil
+}
+
+// TotalSize returns the sum of sizes of cached files
+func (c *Cache) TotalSize() (size int64) {
+	for _, e := range c.Entries {
+		size += e.Size
+	}
+	return size
+}
+
+// BlobPath returns the path of cached file of entry like "<dir>/blobs/sha256/<digest>"
+func (c *Cache) BlobPath(entry Entry) (path string) {
+	s, _, t := entry.Checksum.GetSumAndHasher()
+	return filepath.Join(c.dir, blobDirName, t.String(), s)
+}
+
+// update reloads cache index; applies fn to it; and saves it. Updates are serialized so that
+// concurrent updates by Runners in the process are not lost
+func (c *Cache) update(fn func() error) (err error) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	latest, err := Open(c.dir)
+	if err != nil {
+		return err
+	}
+	c.Entries = latest.Entries
+	if err = fn(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+func (c *Cache) indexPath() (path string) {
+	return filepath.Join(c.dir, indexFileName)
+}
+
+func (c *Cache) remove(match func(Entry) bool) {
+	kept := []Entry{}
+	for _, e := range c.Entries {
+		if !match(e) {
+			kept = append(kept, e)
+		}
+	}
+	c.Entries = kept
+}
+
+// removeBlob deletes cached file of entry unless other entry refers to it
+func (c *Cache) removeBlob(entry Entry) (err error) {
+	blob := c.BlobPath(entry)
+	for _, e := range c.Entries {
+		if c.BlobPath(e) == blob {
+			return nil
+		}
+	}
+	if _err := os.Remove(blob); _err != nil && !os.IsNotExist(_err) {
+		return erron.Errorwf(_err, "Failed to remove cached file: %s", blob)
+	}
+	return nil
+}
+
+func (c *Cache) ToText() (text string) {
+	format := "%-32s    %10s    %-16s    %s"
+	a := []string{fmt.Sprintf(format, "File", "Size", "Last Used", "URL")}
+	a = append(a, fmt.Sprint(strings.Repeat("=", 96)))
+	for _, e := range c.Entries {
+		a = append(a, fmt.Sprintf(format,
+			e.File, FormatSize(e.Size), e.AccessedAt.Local().Format("2006-01-02 15:04"), e.URL))
+	}
+	a = append(a, fmt.Sprintf("Total: %s in %d files", FormatSize(c.TotalSize()), len(c.Entries)))
+	return strings.Join(a, "\n") + "\n"
+}
+
+// FormatSize converts size in bytes into human readable string like "1.5MiB"
+func FormatSize(size int64) (s string) {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func matchAny(cached, cs *item.ItemChecksum) bool {
+	for _, d := range cs.GetDigests() {
+		if s := cached.GetSum(d.Type); s != "" && strings.EqualFold(s, d.Expected) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyFile(src, dest string) (err error) {
+	fin, _err := os.Open(src)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to open file: %s", src)
+	}
+	defer fin.Close()
+
+	dir := filepath.Dir(dest)
+	if _err = os.MkdirAll(dir, 0755); _err != nil {
+		return erron.Errorwf(_err, "Can't make directory: %s", dir)
+	}
+	// Write into temporary file and rename it not to leave broken file in cache
+	fout, _err := ioutil.TempFile(dir, ".tmp-*")
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to create file in: %s", dir)
+	}
+	defer os.Remove(fout.Name())
+	if _, _err = io.Copy(fout, fin); _err != nil {
+		fout.Close()
+		return erron.Errorwf(_err, "Failed to copy file: %s => %s", src, dest)
+	}
+	if _err = fout.Close(); _err != nil {
+		return erron.Errorwf(_err, "Failed to write file: %s", fout.Name())
+	}
+	if _err = os.Rename(fout.Name(), dest); _err != nil {
+		return erron.Errorwf(_err, "Failed to rename file: %s => %s", fout.Name(), dest)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/internal/erron"
+)
+
+const partialDirName = "partial"
+
+// Partial is a file being downloaded into cache directory. When download is interrupted, the file
+// is kept so that the download can be resumed later
+type Partial struct {
+	URL string `json:"url"`
+	// ETag or Last-Modified of the response. It is sent in If-Range header on resumption
+	Validator string `json:"validator"`
+	path      string
+}
+
+// Partial returns partial download of url. Content downloaded before is loaded if it exists
+func (c *Cache) Partial(url string) (p *Partial, err error) {
+	name := fmt.Sprintf("%x", sha256.Sum256([]byte(url)))
+	p = &Partial{URL: url, path: filepath.Join(c.dir, partialDirName, name+".part")}
+	raw, _err := ioutil.ReadFile(p.metaPath())
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return p, nil
+		}
+		return nil, erron.Errorwf(_err, "Can't read file: %s", p.metaPath())
+	}
+	if _err = json.Unmarshal(raw, p); _err != nil || p.URL != url {
+		// Broken or conflicting one. Download from scratch
+		p.URL, p.Validator = url, ""
+	}
+	return p, nil
+}
+
+// Path returns the path of partial file
+func (p *Partial) Path() (path string) {
+	return p.path
+}
+
+// Size returns the size of content which can be resumed
+func (p *Partial) Size() (size int64) {
+	if p.Validator == "" {
+		return 0
+	}
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// Fetch downloads the content of URL into partial file. When content downloaded before remains,
+// only the rest is requested by Range and If-Range headers. It falls back to full download when the
+// server does not support Range requests or the content has changed.
+// It returns the offset from which the download is resumed. On failure, downloaded content is kept.
+// progress is called while downloading unless it is nil
+func (p *Partial) Fetch(ctx context.Context, hc *http.Client, progress http.ProgressFunc) (resumed int64, err error) {
+	dir := filepath.Dir(p.path)
+	if _err := os.MkdirAll(dir, 0755); _err != nil {
+		return 0, erron.Errorwf(_err, "Can't make directory: %s", dir)
+	}
+
+	offset := p.Size()
+	res, _err := hc.FetchRangeContext(ctx, p.URL, offset, p.Validator)
+	if _err != nil {
+		return 0, erron.Errorwf(_err, "Failed to execute HTTP request")
+	}
+	defer res.Body.Close()
+
+	flag := os.O_WRONLY | os.O_CREATE
+	switch {
+	case res.StatusCode == 206 && offset > 0 && http.RangeStart(res) == offset:
+		flag |= os.O_APPEND
+		resumed = offset
+	case res.StatusCode == 200:
+		flag |= os.O_TRUNC
+	case (res.StatusCode == 416 || res.StatusCode == 206) && offset > 0:
+		// Partial file may be stale; or the server returns unexpected range. Download from scratch
+		if err = p.Remove(); err != nil {
+			return 0, err
+		}
+		return p.Fetch(ctx, hc, progress)
+	default:
+		return 0, fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", res.StatusCode, p.URL)
+	}
+
+	p.Validator = http.Validator(res)
+	if err = p.save(); err != nil {
+		return 0, err
+	}
+	f, _err := os.OpenFile(p.path, flag, 0644)
+	if _err != nil {
+		return 0, erron.Errorwf(_err, "Failed to open file: %s", p.path)
+	}
+	defer f.Close()
+	http.WithProgress(res, resumed, progress)
+	if _, _err = io.Copy(f, res.Body); _err != nil {
+		return resumed, erron.Errorwf(_err, "Failed to read HTTP response")
+	}
+	return resumed, nil
+}
+
+// Open opens the partial file to read
+func (p *Partial) Open() (f *os.File, err error) {
+	f, _err := os.Open(p.path)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to open file: %s", p.path)
+	}
+	return f, nil
+}
+
+// Remove deletes the partial file
+func (p *Partial) Remove() (err error) {
+	for _, path := range []string{p.path, p.metaPath()} {
+		if _err := os.Remove(path); _err != nil && !os.IsNotExist(_err) {
+			return erron.Errorwf(_err, "Failed to remove file: %s", path)
+		}
+	}
+	p.Validator = ""
+	return nil
+}
+
+func (p *Partial) save() (err error) {
+	b, _err := json.Marshal(p)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to marshal JSON: %+v", *p)
+	}
+	if _err = ioutil.WriteFile(p.metaPath(), b, 0644); _err != nil {
+		return erron.Errorwf(_err, "Can't write file: %s", p.metaPath())
+	}
+	return nil
+}
+
+func (p *Partial) metaPath() (path string) {
+	return p.path + ".json"
+}
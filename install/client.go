@@ -0,0 +1,33 @@
+package install
+
+import (
+	"net/url"
+
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/internal/config"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/progrhyme/go-lv"
+)
+
+// newClient creates Client for the server applying settings in configuration file.
+// Configuration is not used when configPath is empty
+func newClient(server *url.URL, configPath string, opts http.Options, logger lv.Granular) (c *client.Client, err error) {
+	if c, err = client.NewClient(server, logger, opts); err != nil {
+		return nil, err
+	}
+	if configPath == "" {
+		return c, nil
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if key := cfg.GetServer(server.String()).PublicKey; key != "" {
+		if _err := c.SetPublicKey(key); _err != nil {
+			return nil, erron.Errorwf(_err, "Invalid public key for server: %s. Config: %s", server, configPath)
+		}
+		logger.Debugf("Verify signatures of data on server: %s", server)
+	}
+	return c, nil
+}
@@ -0,0 +1,79 @@
+package install
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/progrhyme/go-lv"
+)
+
+func TestSignedIndex(t *testing.T) {
+	itemJSON := func(r *http.Request) []byte {
+		return []byte(fmt.Sprintf(testItemJSONFormat, r.Host))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testContent))
+	})
+	mux.HandleFunc("/foo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(itemJSON(r))
+	})
+	mux.HandleFunc("/foo/index.json.sig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(testSignKey, itemJSON(r)))))
+	})
+	// Item without signature
+	mux.HandleFunc("/bar", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(itemJSON(r))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	keys := map[string]ed25519.PrivateKey{"trusted": testSignKey, "other": otherKey}
+	log := &strings.Builder{}
+	testCases := []struct {
+		source, key string
+		success     bool
+	}{
+		{source: "foo", key: "trusted", success: true},
+		{source: "foo", key: "other"},
+		{source: "bar", key: "trusted"},
+		{source: "bar", success: true},
+	}
+	for i, tc := range testCases {
+		dir := filepath.Join(tmpdir, fmt.Sprint(i))
+		os.Mkdir(dir, 0755)
+		cfgPath := filepath.Join(dir, "config.json")
+		if tc.key != "" {
+			pub := base64.StdEncoding.EncodeToString(keys[tc.key].Public().(ed25519.PublicKey))
+			cfg := fmt.Sprintf(`{"servers": {"%s/": {"public-key": "%s"}}}`, ts.URL, pub)
+			if err := ioutil.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+				t.Fatalf("Failed to write config. %v", err)
+			}
+		}
+		err := Run(RunOption{
+			Source:     tc.source,
+			DestDir:    dir,
+			Output:     log,
+			LogLevel:   lv.LNotice,
+			ServerURL:  ts.URL,
+			ConfigPath: cfgPath,
+		})
+		if tc.success != (err == nil) || (!tc.success && !errors.Is(err, ErrSignatureInvalid)) {
+			t.Errorf("[%d] Unexpected result. Success: %v, Got: %v", i, tc.success, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "foo")); tc.success != (err == nil) {
+			t.Errorf("[%d] Installed file mismatch. Success: %v", i, tc.success)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package install
 
 import (
-	"encoding/hex"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,23 +11,36 @@ import (
 	"path/filepath"
 
 	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/install/cache"
 	"github.com/binqry/binq/internal/erron"
 	"github.com/binqry/binq/schema/item"
 )
 
-func (r *Runner) fetch() (err error) {
+func (r *Runner) fetch(ctx context.Context) (err error) {
 	if r.sourceURL == "" {
 		return fmt.Errorf("Can't fetch because sourceURL is not set. Source: %s", r.Source)
 	}
-	r.Logger.Printf("GET %s", r.sourceURL)
-	res, _err := http.Fetch(r.sourceURL)
+	url, _err := url.Parse(r.sourceURL)
 	if _err != nil {
-		return erron.Errorwf(_err, "Failed to execute HTTP request")
+		// Unexpected case
+		return erron.Errorwf(_err, "Failed to parse source URL: %v", r.sourceURL)
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		return fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", res.StatusCode, r.Source)
+	base := path.Base(url.Path)
+
+	cs, err := r.getChecksum(ctx, base)
+	if err != nil {
+		return err
 	}
+	if !r.SkipVerify {
+		if cs != nil && len(cs.GetDigests()) == 0 {
+			// Checksum declared only in unsupported algorithms can't verify anything
+			return erron.Errorwf(ErrChecksumNotProvided, "No usable checksum. File: %s", base)
+		}
+		if cs == nil && r.RequireChecksum {
+			return erron.Errorwf(ErrChecksumNotProvided, "File: %s", base)
+		}
+	}
+
 	r.tmpdir, _err = ioutil.TempDir(os.TempDir(), "binq.*")
 	if _err != nil {
 		return erron.Errorwf(_err, "Failed to create tempdir")
@@ -37,52 +50,258 @@ func (r *Runner) fetch() (err error) {
 			os.RemoveAll(r.tmpdir)
 		}
 	}()
+	r.download = filepath.Join(r.tmpdir, base)
 
-	url, _err := url.Parse(r.sourceURL)
-	if _err != nil {
-		// Unexpected case
-		return erron.Errorwf(_err, "Failed to parse source URL: %v", r.sourceURL)
+	if r.Offline {
+		return r.fetchOffline(cs)
 	}
-	base := path.Base(url.Path)
-	r.download = filepath.Join(r.tmpdir, base)
+	if cs != nil && !r.SkipVerify {
+		if hit, _err := r.fetchFromCache(cs); _err != nil {
+			r.Logger.Warnf("Failed to use cache. %v", _err)
+		} else if hit {
+			return nil
+		}
+	}
+
+	r.Logger.Printf("GET %s", r.sourceURL)
+	content, partial, err := r.downloadContent(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		content.Close()
+		if partial != nil {
+			// Complete content should not be resumed even if it is corrupt
+			partial.Remove()
+		}
+	}()
+
 	dl, _err := os.Create(r.download)
 	if _err != nil {
 		return erron.Errorwf(_err, "Failed to open file: %s", r.download)
 	}
 	defer dl.Close()
 
-	if r.sourceItem != nil {
-		if cs := r.sourceItem.GetChecksum(base); cs != nil {
-			return r.downloadWithChecksum(cs, res.Body, dl)
+	switch {
+	case r.SkipVerify:
+		r.Logger.Warnf("Skip checksum verification")
+	case cs != nil:
+		if err = r.downloadWithChecksum(cs, content, dl); err != nil {
+			return err
 		}
+		r.storeCache()
+		return nil
+	case r.sourceItem != nil:
 		r.Logger.Noticef("Checksum is not provided. Skip verification")
 	}
 
-	// Download without checksum
-	_, _err = io.Copy(dl, res.Body)
+	// Download without checksum. Calculate SHA-256 to record
+	digest := item.NewDigest(item.ChecksumTypeSHA256, "")
+	_, _err = io.Copy(dl, io.TeeReader(content, digest))
 	if _err != nil {
 		return erron.Errorwf(_err, "Failed to read HTTP response")
 	}
 	r.Logger.Debugf("Saved file %s", r.download)
+	r.downloadSum = &item.ItemChecksum{File: base, SHA256: digest.Sum()}
+	// Cached file without verification is not reused until its checksum is known
+	r.storeCache()
 
 	return nil
 }
 
+// downloadContent sends HTTP request for sourceURL and returns its content.
+// When CacheDir is set, content is downloaded into a partial file in the cache at first so that
+// interrupted download can be resumed later. The partial file should be removed after use
+func (r *Runner) downloadContent(ctx context.Context) (content io.ReadCloser, partial *cache.Partial, err error) {
+	hc, err := r.getHTTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.CacheDir == "" {
+		res, _err := hc.FetchContext(ctx, r.sourceURL)
+		if _err != nil {
+			return nil, nil, erron.Errorwf(_err, "Failed to execute HTTP request")
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			return nil, nil, fmt.Errorf("HTTP response is not OK. Code: %d, URL: %s", res.StatusCode, r.Source)
+		}
+		http.WithProgress(res, 0, r.progressFunc())
+		return res.Body, nil, nil
+	}
+
+	c, err := cache.Open(r.CacheDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if partial, err = c.Partial(r.sourceURL); err != nil {
+		return nil, nil, err
+	}
+	resumed, err := partial.Fetch(ctx, hc, r.progressFunc())
+	if resumed > 0 {
+		r.Logger.Infof("Resumed download from %d bytes", resumed)
+	}
+	if err != nil {
+		if partial.Size() > 0 {
+			r.Logger.Noticef("Download is interrupted. Run again to resume it")
+		}
+		return nil, nil, err
+	}
+	if content, err = partial.Open(); err != nil {
+		return nil, nil, err
+	}
+	return content, partial, nil
+}
+
+// fetchFromCache copies cached file which has checksum cs into download path.
+// It returns true when the file is found and verified
+func (r *Runner) fetchFromCache(cs *item.ItemChecksum) (hit bool, err error) {
+	if r.CacheDir == "" {
+		return false, nil
+	}
+	c, err := cache.Open(r.CacheDir)
+	if err != nil {
+		return false, err
+	}
+	entry := c.Lookup(r.sourceURL, cs)
+	if entry == nil {
+		r.Logger.Debugf("Cache miss: %s", r.sourceURL)
+		return false, nil
+	}
+	if err = r.copyFromCache(c, entry, cs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fetchOffline copies the file from cache without network access.
+// When checksum is not known, the file cached for the same URL is used
+func (r *Runner) fetchOffline(cs *item.ItemChecksum) (err error) {
+	if r.CacheDir == "" {
+		return erron.Errorwf(ErrNotCached, "Download cache is disabled. URL: %s", r.sourceURL)
+	}
+	c, err := cache.Open(r.CacheDir)
+	if err != nil {
+		return err
+	}
+
+	var entry *cache.Entry
+	switch {
+	case r.SkipVerify:
+		r.Logger.Warnf("Skip checksum verification")
+		entry = c.LookupURL(r.sourceURL)
+	case cs != nil:
+		entry = c.Lookup(r.sourceURL, cs)
+	default:
+		if r.sourceItem != nil {
+			r.Logger.Noticef("Checksum is not provided. Skip verification")
+		}
+		entry = c.LookupURL(r.sourceURL)
+	}
+	if entry == nil {
+		return erron.Errorwf(ErrNotCached, "Missing in download cache: %s", r.sourceURL)
+	}
+	if cs == nil || r.SkipVerify {
+		// Still detect broken cache by the checksum recorded on caching
+		cs = &entry.Checksum
+	}
+	return r.copyFromCache(c, entry, cs)
+}
+
+// copyFromCache copies cached file of entry into download path verifying it with cs.
+// Broken cache is removed
+func (r *Runner) copyFromCache(c *cache.Cache, entry *cache.Entry, cs *item.ItemChecksum) (err error) {
+	src, err := c.Open(*entry)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dl, _err := os.Create(r.download)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to open file: %s", r.download)
+	}
+	defer dl.Close()
+	if err = r.downloadWithChecksum(cs, src, dl); err != nil {
+		c.Remove(*entry)
+		return err
+	}
+	r.Logger.Printf("Use cached file for %s", r.sourceURL)
+	return c.Touch(entry)
+}
+
+// storeCache saves downloaded file into cache. Failure is not fatal for installation
+func (r *Runner) storeCache() {
+	if r.CacheDir == "" || r.downloadSum == nil {
+		return
+	}
+	c, err := cache.Open(r.CacheDir)
+	if err == nil {
+		_, err = c.Put(r.sourceURL, r.download, r.downloadSum)
+	}
+	if err != nil {
+		r.Logger.Warnf("Failed to store file in cache. %v", err)
+		return
+	}
+	r.Logger.Debugf("Stored %s in cache %s", r.download, r.CacheDir)
+}
+
+// getChecksum returns checksum to verify downloaded file.
+// The one in Lockfile is preferred to the one in Item Manifest.
+// When Item Manifest has no checksum for the file, checksum file published by upstream is consulted.
+func (r *Runner) getChecksum(ctx context.Context, file string) (cs *item.ItemChecksum, err error) {
+	if r.locked != nil {
+		return r.locked.Checksum(file)
+	}
+	if r.sourceItem == nil {
+		return nil, nil
+	}
+	if cs = r.sourceItem.GetChecksum(file); cs != nil {
+		return cs, nil
+	}
+
+	sumURL, err := r.sourceItem.GetChecksumURL(item.FormatParam{OS: r.os, Arch: r.arch})
+	if err != nil || sumURL == "" {
+		return nil, err
+	}
+	clt, err := r.getClient()
+	if err != nil {
+		return nil, err
+	}
+	sums, err := clt.GetChecksumsContext(ctx, sumURL, file)
+	if err != nil {
+		return nil, err
+	}
+	for _, sum := range sums {
+		if sum.File == file {
+			return &sum, nil
+		}
+	}
+	r.Logger.Noticef("Checksum file does not contain %s. URL: %s", file, sumURL)
+	return nil, nil
+}
+
+// downloadWithChecksum saves content into destFile hashing it by all algorithms declared in cs.
+// Every checksum must match; and at least one checksum must be declared.
 func (r *Runner) downloadWithChecksum(cs *item.ItemChecksum, content io.ReadCloser, destFile *os.File) (err error) {
-	sum, hasher, _ := cs.GetSumAndHasher()
-	tee := io.TeeReader(content, hasher)
+	digests := cs.GetDigests()
+	if len(digests) == 0 {
+		return erron.Errorwf(ErrChecksumNotProvided, "No usable checksum. File: %s", cs.File)
+	}
+	tee := io.TeeReader(content, item.DigestWriter(digests))
 	_, _err := io.Copy(destFile, tee)
 	if _err != nil {
 		return erron.Errorwf(_err, "Failed to read HTTP response")
 	}
 	r.Logger.Debugf("Saved file %s", r.download)
 
-	cksum := hex.EncodeToString(hasher.Sum(nil))
-	r.Logger.Debugf("Sum: %s", cksum)
-	if sum != cksum {
-		r.Logger.Errorf("Checksum differs! Maybe corrupt? Want: %s, Got: %s", sum, cksum)
-	} else {
-		r.Logger.Infof("Checksum is OK")
+	for _, d := range digests {
+		r.Logger.Debugf("Sum(%s): %s", d.Type, d.Sum())
+		if !d.Match() {
+			return erron.Errorwf(ErrChecksumMismatch, "File: %s, Algorithm: %s, Want: %s, Got: %s",
+				cs.File, d.Type, d.Expected, d.Sum())
+		}
 	}
+	r.Logger.Infof("Checksum is OK")
+	r.downloadSum = cs
 	return nil
 }
@@ -0,0 +1,405 @@
+package install
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/binqry/binq/install/cache"
+	"github.com/progrhyme/go-lv"
+)
+
+// testCorruptItemJSONFormat represents an item whose checksum does not match the content
+const testCorruptItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/download/bar-{{.Version}}"
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0",
+      "checksums": [
+        {
+          "file": "bar-0.1.0",
+          "sha256": "0000000000000000000000000000000000000000000000000000000000000000"
+        }
+      ]
+    }
+  ]
+}`
+
+// testMultiSumItemJSONFormat represents an item which has correct SHA-256 but wrong MD5 checksum
+const testMultiSumItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/download/baz-{{.Version}}"
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0",
+      "checksums": [
+        {
+          "file": "baz-0.1.0",
+          "sha256": "%x",
+          "md5": "00000000000000000000000000000000"
+        }
+      ]
+    }
+  ]
+}`
+
+// testUnsupportedSumItemJSONFormat represents an item whose checksum is declared only in an
+// unsupported algorithm
+const testUnsupportedSumItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/download/corge-{{.Version}}"
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0",
+      "checksums": [
+        {
+          "file": "corge-0.1.0",
+          "sha1": "0000000000000000000000000000000000000000"
+        }
+      ]
+    }
+  ]
+}`
+
+// testSumFileItemJSONFormat represents an item whose checksums are published in checksum file
+const testSumFileItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/download/%s-{{.Version}}",
+    "checksum-url-format": "http://%s/sums/%s"
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0"
+    }
+  ]
+}`
+
+// newChecksumTestMux returns ServeMux which serves "foo" without checksum; corrupt items "bar"
+// and "baz"; "corge" without usable checksum; and items "qux" and "quux" whose checksums are
+// published in checksum files
+func newChecksumTestMux() (mux *http.ServeMux) {
+	mux = newTestMux(map[string]string{
+		"foo":   testItemJSONFormat,
+		"bar":   testCorruptItemJSONFormat,
+		"corge": testUnsupportedSumItemJSONFormat,
+	})
+	mux.HandleFunc("/baz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, testMultiSumItemJSONFormat, r.Host, sha256.Sum256([]byte(testContent)))
+	})
+	mux.HandleFunc("/qux", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, testSumFileItemJSONFormat, r.Host, "qux", r.Host, "SHA256SUMS")
+	})
+	mux.HandleFunc("/quux", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, testSumFileItemJSONFormat, r.Host, "quux", r.Host, "quux-{{.Version}}.sha512")
+	})
+	// GNU coreutils style
+	mux.HandleFunc("/sums/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%x  qux-0.1.0\n%064d  quux-0.1.0\n", sha256.Sum256([]byte(testContent)), 0)
+	})
+	// BSD style with wrong checksum
+	mux.HandleFunc("/sums/quux-0.1.0.sha512", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "SHA512 (quux-0.1.0) = %0128d\n", 0)
+	})
+	return mux
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	ts := httptest.NewServer(newChecksumTestMux())
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	log := &strings.Builder{}
+	testCases := []struct {
+		source                string
+		skipVerify, requireCS bool
+		want                  error
+	}{
+		{source: "bar", want: ErrChecksumMismatch},
+		{source: "bar", skipVerify: true, want: nil},
+		{source: "baz", want: ErrChecksumMismatch},
+		{source: "qux", requireCS: true, want: nil},
+		{source: "quux", want: ErrChecksumMismatch},
+		{source: "corge", want: ErrChecksumNotProvided},
+		{source: "corge", skipVerify: true, want: nil},
+		{source: "foo", requireCS: true, want: ErrChecksumNotProvided},
+		{source: ts.URL + "/download/foo", requireCS: true, want: ErrChecksumNotProvided},
+	}
+	for i, tc := range testCases {
+		dir := filepath.Join(tmpdir, fmt.Sprint(i))
+		os.Mkdir(dir, 0755)
+		err := Run(RunOption{
+			Source:          tc.source,
+			DestDir:         dir,
+			Output:          log,
+			LogLevel:        lv.LNotice,
+			ServerURL:       ts.URL,
+			SkipVerify:      tc.skipVerify,
+			RequireChecksum: tc.requireCS,
+		})
+		if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+			t.Errorf("[%d] Error mismatch. Want: %v, Got: %v", i, tc.want, err)
+		}
+		files, _ := ioutil.ReadDir(dir)
+		if installed := len(files) > 0; installed != (tc.want == nil) {
+			t.Errorf("[%d] Installed files mismatch. Got: %v", i, files)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	var downloads int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write([]byte(testContent))
+	})
+	mux.HandleFunc("/qux", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, testSumFileItemJSONFormat, r.Host, "qux", r.Host, "SHA256SUMS")
+	})
+	mux.HandleFunc("/sums/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%x  qux-0.1.0\n", sha256.Sum256([]byte(testContent)))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	cacheDir := filepath.Join(tmpdir, "cache")
+	log := &strings.Builder{}
+	install := func(i int, source string, skipVerify bool) {
+		dir := filepath.Join(tmpdir, fmt.Sprint(i))
+		os.Mkdir(dir, 0755)
+		err := Run(RunOption{
+			Source:     source,
+			DestDir:    dir,
+			Output:     log,
+			LogLevel:   lv.LNotice,
+			ServerURL:  ts.URL,
+			CacheDir:   cacheDir,
+			SkipVerify: skipVerify,
+		})
+		if err != nil {
+			t.Fatalf("[%d] Install failed. %v\nLog: %s", i, err, log)
+		}
+	}
+
+	testCases := []struct {
+		source     string
+		skipVerify bool
+		corrupt    bool
+		downloads  int
+	}{
+		{source: "qux", downloads: 1},
+		{source: "qux", downloads: 1},
+		// Cache is not used without verification
+		{source: "qux", skipVerify: true, downloads: 2},
+		// Broken cache is discarded
+		{source: "qux", corrupt: true, downloads: 3},
+		{source: "qux", downloads: 3},
+		// Cache is not used without checksum
+		{source: ts.URL + "/download/qux", downloads: 4},
+	}
+	for i, tc := range testCases {
+		if tc.corrupt {
+			c, err := cache.Open(cacheDir)
+			if err != nil {
+				t.Fatalf("[%d] Failed to open cache. %v", i, err)
+			}
+			for _, e := range c.Entries {
+				ioutil.WriteFile(c.BlobPath(e), []byte("broken"), 0644)
+			}
+		}
+		install(i, tc.source, tc.skipVerify)
+		if downloads != tc.downloads {
+			t.Errorf("[%d] Downloads mismatch. Want: %d, Got: %d", i, tc.downloads, downloads)
+		}
+	}
+
+	c, err := cache.Open(cacheDir)
+	if err != nil {
+		t.Fatalf("Failed to open cache. %v", err)
+	}
+	if len(c.Entries) != 2 {
+		t.Fatalf("Cache entries mismatch. Want: 2, Got: %d. Cache: %s", len(c.Entries), c)
+	}
+	if removed, err := c.Prune(0, int64(len(testContent))); err != nil || len(removed) != 1 {
+		t.Errorf("Prune by size failed. Removed: %v, Error: %v", removed, err)
+	}
+	// Remaining entry shares the file with removed one
+	if _, err = os.Stat(c.BlobPath(c.Entries[0])); err != nil {
+		t.Errorf("Cached file not found after prune. %v", err)
+	}
+	if removed, err := c.Prune(time.Nanosecond, 0); err != nil || len(removed) != 1 {
+		t.Errorf("Prune by age failed. Removed: %v, Error: %v", removed, err)
+	}
+	install(len(testCases), "qux", false)
+	if err = c.Clean(); err != nil {
+		t.Errorf("Clean failed. %v", err)
+	}
+	if _, err = os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("Cache directory remains after clean: %s", cacheDir)
+	}
+}
+
+func TestOffline(t *testing.T) {
+	mux := newChecksumTestMux()
+	handleSignedItems(mux)
+	ts := httptest.NewServer(mux)
+	tmpdir := t.TempDir()
+
+	serverURL := ts.URL
+	cacheDir := filepath.Join(tmpdir, "cache")
+	indexCacheDir := filepath.Join(tmpdir, "index")
+	log := &strings.Builder{}
+	sources := []string{"foo@0.1.0", "qux", "signed", serverURL + "/download/qux"}
+	for _, src := range sources {
+		err := Download(RunOption{
+			Source:        src,
+			Output:        log,
+			LogLevel:      lv.LNotice,
+			ServerURL:     serverURL,
+			CacheDir:      cacheDir,
+			IndexCacheDir: indexCacheDir,
+		})
+		if err != nil {
+			t.Fatalf("Download failed. Source: %s, Error: %v\nLog: %s", src, err, log)
+		}
+	}
+	if err := Download(RunOption{Source: "foo", Output: log, ServerURL: serverURL}); err == nil {
+		t.Errorf("Download should fail without cache")
+	}
+	// No network access after this
+	ts.Close()
+
+	testCases := []struct {
+		source  string
+		missing string
+	}{
+		{source: sources[0]},
+		{source: sources[1]},
+		{source: sources[2]},
+		{source: sources[3]},
+		{source: "foo", missing: serverURL + "/download/foo-0.2.0"},
+		{source: "bar", missing: serverURL + "/bar"},
+		{source: serverURL + "/download/bar-0.1.0", missing: serverURL + "/download/bar-0.1.0"},
+	}
+	for i, tc := range testCases {
+		dir := filepath.Join(tmpdir, fmt.Sprint(i))
+		os.Mkdir(dir, 0755)
+		err := Run(RunOption{
+			Source:        tc.source,
+			DestDir:       dir,
+			Output:        log,
+			LogLevel:      lv.LNotice,
+			ServerURL:     serverURL,
+			CacheDir:      cacheDir,
+			IndexCacheDir: indexCacheDir,
+			Offline:       true,
+		})
+		if tc.missing == "" {
+			if err != nil {
+				t.Errorf("[%d] Offline install failed. %v", i, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrNotCached) || !strings.Contains(err.Error(), tc.missing) {
+			t.Errorf("[%d] Error mismatch. Want: %v for %s, Got: %v", i, ErrNotCached, tc.missing, err)
+		}
+	}
+}
+
+func TestResumeDownload(t *testing.T) {
+	content := strings.Repeat("0123456789abcdef", 4096)
+	etag := `"v1"`
+	var interrupt, ignoreRange bool
+	var ranges []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download/big", func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if interrupt {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			w.Write([]byte(content[:len(content)/2]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		if ignoreRange {
+			w.Write([]byte(content))
+			return
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "big", time.Time{}, strings.NewReader(content))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	cacheDir := filepath.Join(tmpdir, "cache")
+	log := &strings.Builder{}
+	testCases := []struct {
+		name        string
+		ignoreRange bool
+		changed     bool
+		wantRange   string
+	}{
+		{name: "resume", wantRange: fmt.Sprintf("bytes=%d-", len(content)/2)},
+		{name: "range not supported", ignoreRange: true, wantRange: fmt.Sprintf("bytes=%d-", len(content)/2)},
+		{name: "content changed", changed: true, wantRange: fmt.Sprintf("bytes=%d-", len(content)/2)},
+	}
+	for i, tc := range testCases {
+		dir := filepath.Join(tmpdir, fmt.Sprint(i))
+		os.Mkdir(dir, 0755)
+		opt := RunOption{
+			Source:   ts.URL + "/download/big",
+			DestDir:  dir,
+			Output:   log,
+			LogLevel: lv.LNotice,
+			CacheDir: cacheDir,
+		}
+		etag, interrupt, ignoreRange = `"v1"`, true, false
+		if err := Run(opt); err == nil {
+			t.Fatalf("[%s] Interrupted download should fail", tc.name)
+		}
+
+		interrupt, ignoreRange, ranges = false, tc.ignoreRange, nil
+		if tc.changed {
+			etag, content = `"v2"`, strings.ToUpper(content)
+		}
+		if err := Run(opt); err != nil {
+			t.Fatalf("[%s] Install failed. %v\nLog: %s", tc.name, err, log)
+		}
+		if len(ranges) != 1 || ranges[0] != tc.wantRange {
+			t.Errorf("[%s] Range header mismatch. Want: %s, Got: %v", tc.name, tc.wantRange, ranges)
+		}
+		got, err := ioutil.ReadFile(filepath.Join(dir, "big"))
+		if err != nil || string(got) != content {
+			t.Errorf("[%s] Installed content mismatch. Size: %d, Error: %v", tc.name, len(got), err)
+		}
+		if files, _ := ioutil.ReadDir(filepath.Join(cacheDir, "partial")); len(files) > 0 {
+			t.Errorf("[%s] Partial files remain: %v", tc.name, files)
+		}
+	}
+}
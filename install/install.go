@@ -5,6 +5,9 @@ import (
 	"errors"
 	"regexp"
 	"runtime"
+
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/internal/signature"
 )
 
 type Mode int
@@ -16,7 +19,15 @@ const (
 	ModeDefault = ModeExtract | ModeExecutable
 )
 
-var ErrVersionNotNewerThanThreshold = errors.New("Item version is not newer than given threshold")
+var (
+	ErrVersionNotNewerThanThreshold = errors.New("Item version is not newer than given threshold")
+	ErrChecksumMismatch             = errors.New("Checksum mismatch")
+	ErrChecksumNotProvided          = errors.New("Checksum is not provided")
+	// ErrSignatureInvalid is the same as signature.ErrVerificationFailed
+	ErrSignatureInvalid = signature.ErrVerificationFailed
+	// ErrNotCached is the same as client.ErrNotCached
+	ErrNotCached = client.ErrNotCached
+)
 
 var (
 	isWindows = runtime.GOOS == "windows"
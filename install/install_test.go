@@ -0,0 +1,74 @@
+package install
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+)
+
+const testContent = "#!/bin/sh\necho foo\n"
+
+// testItemJSONFormat represents an item "foo" which has versions 0.1.0 and 0.2.0
+const testItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/download/foo-{{.Version}}",
+    "rename-files": {
+      "foo-{{.Version}}": "foo"
+    }
+  },
+  "latest": {
+    "version": "0.2.0"
+  },
+  "versions": [
+    {
+      "version": "0.2.0"
+    },
+    {
+      "version": "0.1.0"
+    }
+  ]
+}`
+
+// newTestMux returns ServeMux which works as both index server and download site. It serves
+// testContent for any file under "/download/"; and each of items by path "/NAME" after
+// formatting it with the host of the server
+func newTestMux(items map[string]string) (mux *http.ServeMux) {
+	mux = http.NewServeMux()
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testContent))
+	})
+	for name, format := range items {
+		format := format
+		mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, format, r.Host)
+		})
+	}
+	return mux
+}
+
+type testArchiveFile struct {
+	name string
+	mode os.FileMode
+}
+
+// newZipArchive returns zip archive which has files with their names as contents
+func newZipArchive(t *testing.T, files []testArchiveFile) (b []byte) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		fh := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		fh.SetMode(f.mode)
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry. %v", err)
+		}
+		fmt.Fprintf(w, "%s\n", f.name)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip. %v", err)
+	}
+	return buf.Bytes()
+}
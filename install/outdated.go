@@ -0,0 +1,140 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/schema/item"
+	"github.com/progrhyme/go-lv"
+)
+
+// Outdated represents an installed item which has newer version on the index server
+type Outdated struct {
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	Dir     string `json:"dir"`
+	Server  string `json:"server"`
+	entry   registry.Entry
+}
+
+type OutdatedOption struct {
+	// Names of items to check. All installed items are checked when empty
+	Names    []string
+	Output   io.Writer
+	LogLevel lv.Level
+	// Logger to use instead of the one made of Output and LogLevel
+	Logger lv.Granular
+	// Path to the registry file in which installation is recorded
+	RegistryPath string
+	// Path to configuration file which has settings for index servers. Not used when empty
+	ConfigPath string
+	// Options for HTTP requests such as timeouts and proxy
+	HTTPOptions http.Options
+}
+
+// FindOutdated compares each installed item's version with the latest one on the index server
+// which the item came from.
+// Items installed directly from URLs are not checked because they have no version information.
+func FindOutdated(opt OutdatedOption) (outdated []Outdated, err error) {
+	return FindOutdatedContext(context.Background(), opt)
+}
+
+// FindOutdatedContext is FindOutdated with ctx to abort checks
+func FindOutdatedContext(ctx context.Context, opt OutdatedOption) (outdated []Outdated, err error) {
+	logger := newLogger(opt.Logger, opt.Output, opt.LogLevel)
+
+	reg, err := registry.Load(opt.RegistryPath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := selectEntries(reg, opt.Names)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make(map[string]*client.Client)
+	for _, entry := range entries {
+		if entry.Server == "" {
+			logger.Infof("Skip %s because it is not installed via index server", entry.Name)
+			continue
+		}
+		clt, ok := clients[entry.Server]
+		if !ok {
+			svrURL, _err := url.Parse(entry.Server)
+			if _err != nil {
+				logger.Warnf("Failed to parse server URL: %s. %v", entry.Server, _err)
+				continue
+			}
+			if clt, _err = newClient(svrURL, opt.ConfigPath, opt.HTTPOptions, logger); _err != nil {
+				logger.Warnf("%v", _err)
+				continue
+			}
+			clients[entry.Server] = clt
+		}
+
+		var obj *item.Item
+		var _err error
+		if entry.Path != "" {
+			obj, _err = clt.GetItemInfoByPathContext(ctx, entry.Path)
+		} else {
+			obj, _err = clt.GetItemInfoContext(ctx, entry.Name)
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _err != nil {
+			logger.Warnf("Can't get item data. Name: %s, Server: %s. %v", entry.Name, entry.Server, _err)
+			continue
+		}
+		latest := obj.GetLatest()
+		if latest == nil {
+			logger.Warnf("Latest version is not found. Name: %s, Server: %s", entry.Name, entry.Server)
+			continue
+		}
+
+		newer, _err := isNewerVersion(latest.Version, entry.Version)
+		if _err != nil {
+			logger.Debugf("%s. Compare as string", _err)
+			newer = latest.Version != entry.Version
+		}
+		if !newer {
+			logger.Debugf("%s is up to date: %s", entry.Name, entry.Version)
+			continue
+		}
+		outdated = append(outdated, Outdated{
+			Name:    entry.Name,
+			Current: entry.Version,
+			Latest:  latest.Version,
+			Dir:     entry.Dir,
+			Server:  entry.Server,
+			entry:   entry,
+		})
+	}
+
+	return outdated, nil
+}
+
+func selectEntries(reg *registry.Registry, names []string) (entries []registry.Entry, err error) {
+	if len(names) == 0 {
+		return reg.Entries, nil
+	}
+	for _, name := range names {
+		found := reg.Find(name)
+		if len(found) == 0 {
+			return nil, erron.Errorwf(ErrNotInstalled, "Name: %s", name)
+		}
+		entries = append(entries, found...)
+	}
+	return entries, nil
+}
+
+func (o Outdated) String() string {
+	return fmt.Sprintf("%s %s => %s (%s)", o.Name, o.Current, o.Latest, o.Dir)
+}
@@ -1,28 +1,57 @@
 package install
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 
+	"github.com/binqry/binq/internal/erron"
 	"github.com/binqry/binq/schema/item"
+	"github.com/binqry/binq/schema/project"
 	"github.com/hashicorp/go-version"
 )
 
 // prefetch query metadata for item info to fetch
-func (r *Runner) prefetch() (err error) {
+func (r *Runner) prefetch(ctx context.Context) (err error) {
 	if strings.HasPrefix(r.Source, "http") {
 		r.sourceURL = r.Source
 		return nil
 	}
+
+	name, tgtVer := parseSourceString(r.Source)
+	locked := r.findLockedItem(name, tgtVer)
+	if locked != nil {
+		tgtVer = locked.Version
+		if locked.Server != "" {
+			uri, _err := url.Parse(locked.Server)
+			if _err != nil {
+				return erron.Errorwf(_err, "Failed to parse server URL in Lockfile: %s", locked.Server)
+			}
+			r.ServerURL = uri
+		}
+	}
 	if r.ServerURL == nil {
 		return fmt.Errorf("No server is configured. Can't deal with source: %s", r.Source)
 	}
 
-	name, tgtVer := parseSourceString(r.Source)
-	tgt, _err := r.getClient().GetItemInfo(name)
-	if _err != nil {
-		return _err
+	clt, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	var tgt *item.Item
+	pth := r.ItemPath
+	if locked != nil && locked.Path != "" {
+		pth = locked.Path
+	}
+	if pth != "" {
+		tgt, err = clt.GetItemInfoByPathContext(ctx, pth)
+	} else {
+		tgt, pth, err = clt.LookupItemContext(ctx, name)
+	}
+	if err != nil {
+		return err
 	}
 
 	var rev *item.ItemRevision
@@ -46,28 +75,54 @@ func (r *Runner) prefetch() (err error) {
 		return fmt.Errorf("Can't get source URL from JSON")
 	}
 
+	if locked != nil {
+		if artifact, ok := locked.Platforms[project.Platform(r.os, r.arch)]; ok {
+			if artifact.URL != srcURL {
+				r.Logger.Warnf("URL differs from Lockfile. Use locked one. Locked: %s, Index: %s",
+					artifact.URL, srcURL)
+				srcURL = artifact.URL
+			}
+			r.locked = &artifact
+		}
+	}
+
+	r.itemName = name
+	r.itemPath = pth
 	r.sourceURL = srcURL
 	r.sourceItem = rev
 
 	return nil
 }
 
+// findLockedItem returns locked item data for the item when it is available
+func (r *Runner) findLockedItem(name, version string) (locked *project.LockedItem) {
+	if r.lockfile == nil {
+		return nil
+	}
+	locked = r.lockfile.Find(name)
+	if locked == nil {
+		return nil
+	}
+	if version != "" && version != locked.Version {
+		r.Logger.Noticef("Version differs from Lockfile. Locked: %s, Given: %s. Lockfile will be updated",
+			locked.Version, version)
+		return nil
+	}
+	r.Logger.Infof("Use locked version %s of %s", locked.Version, name)
+	return locked
+}
+
 func (r *Runner) checkItemVersion(rev *item.ItemRevision) (ok bool, err error) {
 	if r.NewerThan == "" {
 		return true, nil
 	}
 
-	sbj, _err := version.NewVersion(rev.Version)
-	if _err != nil {
-		r.Logger.Warnf("Can't parse item's version %s as semantic. Continue installation...", rev.Version)
-		return true, nil
-	}
-	threshold, _err := version.NewVersion(r.NewerThan)
+	newer, _err := isNewerVersion(rev.Version, r.NewerThan)
 	if _err != nil {
-		r.Logger.Warnf("Can't parse given version %s as semantic. Continue installation...", r.NewerThan)
+		r.Logger.Warnf("%s. Continue installation...", _err)
 		return true, nil
 	}
-	if sbj.LessThanOrEqual(threshold) {
+	if !newer {
 		r.Logger.Debugf("Item version %s <= %s. Stop installation.", rev.Version, r.NewerThan)
 		return false, ErrVersionNotNewerThanThreshold
 	}
@@ -75,6 +130,20 @@ func (r *Runner) checkItemVersion(rev *item.ItemRevision) (ok bool, err error) {
 	return true, nil
 }
 
+// isNewerVersion reports whether ver is newer than threshold as semantic versions.
+// It returns error when either of them can't be parsed.
+func isNewerVersion(ver, threshold string) (newer bool, err error) {
+	sbj, _err := version.NewVersion(ver)
+	if _err != nil {
+		return false, fmt.Errorf("Can't parse item's version %s as semantic", ver)
+	}
+	base, _err := version.NewVersion(threshold)
+	if _err != nil {
+		return false, fmt.Errorf("Can't parse given version %s as semantic", threshold)
+	}
+	return sbj.GreaterThan(base), nil
+}
+
 func parseSourceString(src string) (name, version string) {
 	re := regexp.MustCompile(`^([\w\-\./]+)@([\w\-\.]+)$`)
 	if re.MatchString(src) {
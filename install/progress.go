@@ -0,0 +1,78 @@
+package install
+
+import (
+	"time"
+
+	"github.com/binqry/binq/client/http"
+)
+
+// Progress represents the state of downloading a file
+type Progress struct {
+	URL string
+	// Bytes downloaded so far including the ones downloaded before resume
+	Downloaded int64
+	// Size of the file. -1 when it is unknown
+	Total int64
+	// Bytes downloaded before resume
+	Resumed int64
+	// Time elapsed since the download started
+	Elapsed time.Duration
+	// True on the last call for the download
+	Done bool
+}
+
+// ProgressFunc receives Progress of downloads. It is called periodically while downloading a file
+// and once when the download finishes
+type ProgressFunc func(p Progress)
+
+// progressInterval is the minimum interval of calls of ProgressFunc except for the last one
+const progressInterval = 100 * time.Millisecond
+
+// Rate returns average download speed in bytes per second excluding resumed bytes
+func (p Progress) Rate() (bps float64) {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Downloaded-p.Resumed) / p.Elapsed.Seconds()
+}
+
+// ETA returns estimated time to finish the download. It returns -1 when it can't be estimated
+func (p Progress) ETA() (eta time.Duration) {
+	rate := p.Rate()
+	if p.Total < 0 || rate <= 0 {
+		return -1
+	}
+	return time.Duration(float64(p.Total-p.Downloaded) / rate * float64(time.Second))
+}
+
+// progressFunc returns a function to report download progress of sourceURL to ProgressFunc
+// throttling calls. It returns nil when ProgressFunc is not set
+func (r *Runner) progressFunc() (fn http.ProgressFunc) {
+	if r.ProgressFunc == nil {
+		return nil
+	}
+	var (
+		start    = time.Now()
+		last     time.Time
+		resumed  int64 = -1
+		finished bool
+	)
+	return func(read, total int64, done bool) {
+		if finished {
+			return
+		}
+		if resumed < 0 {
+			// The first call is made before reading
+			resumed = read
+		}
+		now := time.Now()
+		if !done && now.Sub(last) < progressInterval {
+			return
+		}
+		last, finished = now, done
+		r.ProgressFunc(Progress{
+			URL: r.sourceURL, Downloaded: read, Total: total, Resumed: resumed,
+			Elapsed: now.Sub(start), Done: done,
+		})
+	}
+}
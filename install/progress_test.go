@@ -0,0 +1,60 @@
+package install
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/progrhyme/go-lv"
+)
+
+func TestProgress(t *testing.T) {
+	content := strings.Repeat("0123456789abcdef", 4096)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download/big", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "big", time.Time{}, strings.NewReader(content))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	for i, cacheDir := range []string{"", filepath.Join(tmpdir, "cache")} {
+		var got []Progress
+		dir := filepath.Join(tmpdir, fmt.Sprint(i))
+		os.Mkdir(dir, 0755)
+		log := &strings.Builder{}
+		opt := RunOption{
+			Source:       ts.URL + "/download/big",
+			DestDir:      dir,
+			Output:       log,
+			LogLevel:     lv.LNotice,
+			CacheDir:     cacheDir,
+			ProgressFunc: func(p Progress) { got = append(got, p) },
+		}
+		if err := Run(opt); err != nil {
+			t.Fatalf("[%d] Install failed. %v\nLog: %s", i, err, log)
+		}
+		if len(got) < 2 {
+			t.Fatalf("[%d] Progress should be reported at start and end. Got: %+v", i, got)
+		}
+		first, last := got[0], got[len(got)-1]
+		if first.Downloaded != 0 || first.Done {
+			t.Errorf("[%d] Unexpected first progress: %+v", i, first)
+		}
+		want := int64(len(content))
+		if !last.Done || last.Downloaded != want || last.Total != want || last.URL != opt.Source {
+			t.Errorf("[%d] Unexpected last progress: %+v", i, last)
+		}
+		for _, p := range got[:len(got)-1] {
+			if p.Done {
+				t.Errorf("[%d] Done is reported before the end: %+v", i, got)
+				break
+			}
+		}
+	}
+}
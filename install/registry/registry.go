@@ -0,0 +1,188 @@
+// Package registry implements persistent database of items installed by binq.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/binqry/binq/internal/atomicfile"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/xdg"
+	"github.com/binqry/binq/schema/item"
+)
+
+const defaultFileName = "installed.json"
+
+// Registry wraps registryProps which corresponds to JSON structure of installation database
+type Registry struct {
+	*registryProps
+	path string
+}
+
+type registryProps struct {
+	Entries []Entry `json:"items"`
+}
+
+// Entry represents a record of installation by binq
+type Entry struct {
+	Name        string             `json:"name"`
+	Version     string             `json:"version,omitempty"`
+	Source      string             `json:"source"`
+	URL         string             `json:"url"`
+	Server      string             `json:"server,omitempty"`
+	Checksum    *item.ItemChecksum `json:"checksum,omitempty"`
+	Dir         string             `json:"dir"`
+	DestFile    string             `json:"dest-file,omitempty"`
+	Mode        int                `json:"mode"`
+	Files       []string           `json:"files"`
+	InstalledAt time.Time          `json:"installed-at"`
+	// Path of Item JSON on the index server. It is used instead of looking up the name in index
+	Path string `json:"path,omitempty"`
+	// Directory of the active version in versioned layout. Files are symbolic links into it
+	PkgDir string `json:"pkg-dir,omitempty"`
+	// Directory for versioned layout specified on installation
+	PkgsDir string `json:"pkgs-dir,omitempty"`
+	// Sources of versions installed in versioned layout keyed by version
+	Pkgs map[string]Pkg `json:"pkgs,omitempty"`
+	// Version which was active before the current one in versioned layout
+	Previous string `json:"previous,omitempty"`
+	// Glob patterns specified on installation to select files in archive
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// Options specified on installation to install a part of archive without executables
+	StripComponents *int   `json:"strip-components,omitempty"`
+	Subdir          string `json:"subdir,omitempty"`
+}
+
+// Pkg represents the source of a version installed in versioned layout
+type Pkg struct {
+	URL      string             `json:"url"`
+	Checksum *item.ItemChecksum `json:"checksum,omitempty"`
+}
+
+// DefaultPath returns the path of registry file under $XDG_DATA_HOME
+func DefaultPath() (path string) {
+	return filepath.Join(xdg.DataDir(), defaultFileName)
+}
+
+// Load reads registry file on given path. It returns empty Registry when the file does not exist
+func Load(path string) (reg *Registry, err error) {
+	reg = &Registry{registryProps: &registryProps{Entries: []Entry{}}, path: path}
+	raw, _err := ioutil.ReadFile(path)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return reg, nil
+		}
+		return nil, erron.Errorwf(_err, "Can't read registry file: %s", path)
+	}
+	if _err = json.Unmarshal(raw, reg.registryProps); _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to unmarshal JSON: %s", path)
+	}
+	return reg, nil
+}
+
+func (reg *Registry) String() string {
+	return fmt.Sprintf("%+v", *reg.registryProps)
+}
+
+// Path returns the file path where reg is loaded from and saved to
+func (reg *Registry) Path() (path string) {
+	return reg.path
+}
+
+// Save writes reg into its file
+func (reg *Registry) Save() (err error) {
+	b, _err := reg.ToJSON(true)
+	if _err != nil {
+		return _err
+	}
+	dir := filepath.Dir(reg.path)
+	if _err = os.MkdirAll(dir, 0755); _err != nil {
+		return erron.Errorwf(_err, "Can't make directory: %s", dir)
+	}
+	if _err = atomicfile.WriteFile(reg.path, append(b, '\n'), 0644); _err != nil {
+		return erron.Errorwf(_err, "Can't write registry file: %s", reg.path)
+	}
+	return nil
+}
+
+func (reg *Registry) ToJSON(pretty bool) (b []byte, err error) {
+	var _err error
+	if pretty {
+		b, _err = json.MarshalIndent(reg.registryProps, "", "  ")
+	} else {
+		b, _err = json.Marshal(reg.registryProps)
+	}
+	if _err != nil {
+		return b, erron.Errorwf(_err, "Failed to marshal JSON: %s", reg)
+	}
+	return b, nil
+}
+
+func (reg *Registry) ToText() (text string) {
+	format := "%-16s    %-12s    %-36s"
+	a := []string{fmt.Sprintf(format, "Name", "Version", "Directory")}
+	a = append(a, fmt.Sprint(strings.Repeat("=", 72)))
+	for _, e := range reg.Entries {
+		a = append(a, fmt.Sprintf(format, e.Name, e.Version, e.Dir))
+	}
+	return strings.Join(a, "\n") + "\n"
+}
+
+// Find returns entries which have given name
+func (reg *Registry) Find(name string) (entries []Entry) {
+	for _, e := range reg.Entries {
+		if e.Name == name {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Get returns an entry specified by name and directory
+func (reg *Registry) Get(name, dir string) (entry *Entry) {
+	for _, e := range reg.Entries {
+		if e.Name == name && e.Dir == dir {
+			return &e
+		}
+	}
+	return nil
+}
+
+// Add adds entry into reg. An existing entry with the same name and directory is replaced
+func (reg *Registry) Add(entry Entry) {
+	for i, e := range reg.Entries {
+		if e.Name == entry.Name && e.Dir == entry.Dir {
+			reg.Entries[i] = entry
+			return
+		}
+	}
+	reg.Entries = append(reg.Entries, entry)
+	sort.SliceStable(reg.Entries, func(i, j int) bool {
+		if reg.Entries[i].Name == reg.Entries[j].Name {
+			return reg.Entries[i].Dir < reg.Entries[j].Dir
+		}
+		return reg.Entries[i].Name < reg.Entries[j].Name
+	})
+}
+
+// Remove removes entries which have given name from reg.
+// When dir is not empty, only the entry installed in dir is removed.
+func (reg *Registry) Remove(name, dir string) (removed []Entry) {
+	kept := []Entry{}
+	for _, e := range reg.Entries {
+		if e.Name == name && (dir == "" || e.Dir == dir) {
+			removed = append(removed, e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	reg.Entries = kept
+	return removed
+}
@@ -1,6 +1,7 @@
 package install
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/url"
@@ -8,32 +9,65 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/binqry/binq"
 	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/install/registry"
 	"github.com/binqry/binq/internal/erron"
 	"github.com/binqry/binq/schema/item"
+	"github.com/binqry/binq/schema/project"
 	"github.com/mholt/archiver/v3"
 	"github.com/progrhyme/go-lv"
 )
 
 type Runner struct {
-	Mode       Mode
-	Source     string
-	DestDir    string
-	DestFile   string
-	Logger     lv.Granular
-	ServerURL  *url.URL
-	NewerThan  string
-	clt        *client.Client
-	sourceURL  string
-	sourceItem *item.ItemRevision
-	os         string
-	arch       string
-	tmpdir     string
-	download   string
-	extractDir string
-	extracted  bool
+	Mode            Mode
+	Source          string
+	DestDir         string
+	DestFile        string
+	Logger          lv.Granular
+	ServerURL       *url.URL
+	ItemPath        string
+	NewerThan       string
+	RegistryPath    string
+	LockfilePath    string
+	ConfigPath      string
+	CacheDir        string
+	IndexCacheDir   string
+	Offline         bool
+	SkipVerify      bool
+	SkipSignature   bool
+	RequireChecksum bool
+	HTTPOptions     http.Options
+	ProgressFunc    ProgressFunc
+	PkgsDir         string
+	Include         []string
+	Exclude         []string
+	ShareDir        string
+	AuxDirs         map[AuxRole]string
+	StripComponents *int
+	Subdir          string
+	err             error
+	clt             *client.Client
+	hc              *http.Client
+	lockfile        *project.Lockfile
+	locked          *project.LockedArtifact
+	itemName        string
+	itemPath        string
+	sourceURL       string
+	sourceItem      *item.ItemRevision
+	downloadSum     *item.ItemChecksum
+	os              string
+	arch            string
+	tmpdir          string
+	download        string
+	extractDir      string
+	extracted       bool
+	installed       []string
+	pkgDir          string
 }
 
 type RunOption struct {
@@ -44,26 +78,127 @@ type RunOption struct {
 	Output    io.Writer
 	LogLevel  lv.Level
 	ServerURL string
+	// Path of Item JSON on the index server. Index is not consulted to find the item when set
+	ItemPath  string
 	NewerThan string
+	// Logger to use instead of the one made of Output and LogLevel
+	Logger lv.Granular
+	// Path to the registry file to record installation. Installation is not recorded when empty
+	RegistryPath string
+	// Path to Lockfile to pin resolved URL and checksum. Lockfile is not used when empty
+	LockfilePath string
+	// Path to configuration file which has settings for index servers. Not used when empty
+	ConfigPath string
+	// Directory to cache downloaded files. Cache is not used when empty
+	CacheDir string
+	// Directory to cache Index and Item JSON. Cache is not used when empty
+	IndexCacheDir string
+	// Resolve items and download files only from caches without network access
+	Offline bool
+	// Install without verifying checksum. This is insecure
+	SkipVerify bool
+	// Install without verifying detached signature declared in Item Manifest. This is insecure
+	SkipSignature bool
+	// Refuse to install when checksum is not provided for the downloaded file
+	RequireChecksum bool
+	// Options for HTTP requests such as timeouts and proxy
+	HTTPOptions http.Options
+	// Callback to receive download progress. Progress is not reported when nil
+	ProgressFunc ProgressFunc
+	// Directory to install items in versioned layout. Each version of an item is installed into
+	// PkgsDir/NAME/VERSION; and DestDir has symbolic links to its files. Not used when empty
+	PkgsDir string
+	// Glob patterns of files in archive to install. They override "files" in Item Manifest.
+	// A pattern without "/" matches base name of files; otherwise the path from archive root
+	Include []string
+	// Glob patterns of files in archive not to install
+	Exclude []string
+	// Directory to put non-executable files selected by Include or "files". They are placed into
+	// ShareDir/NAME. Defaults to DefaultShareDir()
+	ShareDir string
+	// Directories to install auxiliary files declared by "aux-files" in Item Manifest. They
+	// override "dirs" in configuration file and DefaultAuxDirs()
+	AuxDirs map[AuxRole]string
+	// Number of leading path components stripped from archive when it is installed without
+	// executables. It overrides "strip-components" in Item Manifest unless nil; so 0 disables it
+	StripComponents *int
+	// Subdirectory in archive to install without executables. It overrides "subdir" in Item
+	// Manifest. When StripComponents or Subdir is used, the directory is placed in DestDir as
+	// DestFile, the item name, or the archive name in this order
+	Subdir string
 }
 
-var defaultRunner Runner
+// stateMu serializes updates of registry file and Lockfile by Runners running concurrently
+var stateMu sync.Mutex
 
+// Run installs an item by a new Runner with opt. It is a shorthand of New(opt).Run() with
+// background context
 func Run(opt RunOption) (err error) {
-	logger := lv.New(opt.Output, opt.LogLevel, 0)
-	defaultRunner = Runner{
-		Source:    opt.Source,
-		DestDir:   opt.DestDir,
-		DestFile:  opt.DestFile,
-		Logger:    logger,
-		NewerThan: opt.NewerThan,
-		os:        runtime.GOOS,
-		arch:      runtime.GOARCH,
+	return New(opt).Run(context.Background())
+}
+
+// Download fetches the item and related data into caches without installing it; so that it can
+// be installed in offline mode later. It is a shorthand of New(opt).Download() with background
+// context
+func Download(opt RunOption) (err error) {
+	return New(opt).Download(context.Background())
+}
+
+// New returns a Runner configured by opt. A Runner holds the state of one installation; so
+// Runners for different items can run concurrently. Error on the configuration is returned by
+// Run or Download
+func New(opt RunOption) (r *Runner) {
+	logger := newLogger(opt.Logger, opt.Output, opt.LogLevel)
+	r = &Runner{
+		Source:          opt.Source,
+		DestDir:         opt.DestDir,
+		DestFile:        opt.DestFile,
+		Logger:          logger,
+		ItemPath:        opt.ItemPath,
+		NewerThan:       opt.NewerThan,
+		RegistryPath:    opt.RegistryPath,
+		LockfilePath:    opt.LockfilePath,
+		ConfigPath:      opt.ConfigPath,
+		CacheDir:        opt.CacheDir,
+		IndexCacheDir:   opt.IndexCacheDir,
+		Offline:         opt.Offline,
+		SkipVerify:      opt.SkipVerify,
+		SkipSignature:   opt.SkipSignature,
+		RequireChecksum: opt.RequireChecksum,
+		HTTPOptions:     opt.HTTPOptions,
+		ProgressFunc:    opt.ProgressFunc,
+		PkgsDir:         opt.PkgsDir,
+		Include:         opt.Include,
+		Exclude:         opt.Exclude,
+		ShareDir:        opt.ShareDir,
+		AuxDirs:         opt.AuxDirs,
+		StripComponents: opt.StripComponents,
+		Subdir:          opt.Subdir,
+		os:              runtime.GOOS,
+		arch:            runtime.GOARCH,
 	}
 	if opt.Mode == 0 {
-		defaultRunner.Mode = ModeDefault
+		r.Mode = ModeDefault
 	} else {
-		defaultRunner.Mode = opt.Mode
+		r.Mode = opt.Mode
+	}
+	if r.ShareDir == "" {
+		r.ShareDir = DefaultShareDir()
+	}
+	for _, patterns := range [][]string{r.Include, r.Exclude} {
+		if r.err = validatePatterns(patterns); r.err != nil {
+			return r
+		}
+	}
+	if r.err = validateAuxDirs(r.AuxDirs); r.err != nil {
+		return r
+	}
+	if r.StripComponents != nil && *r.StripComponents < 0 {
+		r.err = fmt.Errorf("Invalid number of components to strip: %d", *r.StripComponents)
+		return r
+	}
+	if r.err = validateSubdir(r.Subdir); r.err != nil {
+		return r
 	}
 
 	var urlStr string
@@ -76,34 +211,103 @@ func Run(opt RunOption) (err error) {
 	}
 	uri, _err := url.Parse(urlStr)
 	if _err != nil {
-		return erron.Errorwf(_err, "Failed to parse server URL: %s", urlStr)
+		r.err = erron.Errorwf(_err, "Failed to parse server URL: %s", urlStr)
+		return r
+	}
+	r.ServerURL = uri
+	if r.HTTPOptions.Logger == nil {
+		r.HTTPOptions.Logger = logger
 	}
-	defaultRunner.ServerURL = uri
 
-	return defaultRunner.Run()
+	return r
 }
 
-func (r *Runner) Run() (err error) {
-	if _err := r.prefetch(); _err != nil {
+// newLogger returns logger when it is given. Otherwise, it returns a new Logger which writes
+// to out at level
+func newLogger(logger lv.Granular, out io.Writer, level lv.Level) lv.Granular {
+	if logger != nil {
+		return logger
+	}
+	return lv.New(out, level, 0)
+}
+
+// Run installs the item. When ctx is done, requests in progress are aborted and the following
+// steps are skipped. Temporary files are removed in any case
+func (r *Runner) Run(ctx context.Context) (err error) {
+	if r.err != nil {
+		return r.err
+	}
+	if r.LockfilePath != "" {
+		if r.lockfile, err = project.LoadLockfile(r.LockfilePath); err != nil {
+			return err
+		}
+	}
+	if _err := r.prefetch(ctx); _err != nil {
 		return erron.Errorwf(_err, "Can't fetch item data. Target: %s, Server: %s", r.Source, r.ServerURL)
 	}
-	if err = r.fetch(); err != nil {
+	if err = r.fetch(ctx); err != nil {
 		return err
 	}
 	defer os.RemoveAll(r.tmpdir)
+	if err = r.verifySignature(ctx); err != nil {
+		return err
+	}
 	if r.Mode&ModeExtract != 0 {
-		if err = r.extract(); err != nil {
+		if err = r.extract(ctx); err != nil {
 			return err
 		}
 	}
-	if err = r.locate(); err != nil {
+	if err = r.locate(ctx); err != nil {
 		return err
 	}
+	if r.RegistryPath != "" {
+		if err = r.record(); err != nil {
+			return err
+		}
+	}
+	if r.lockfile != nil && r.sourceItem != nil {
+		if err = r.updateLockfile(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (r *Runner) extract() (err error) {
+// Download fetches the item into download cache verifying its checksum and signature.
+// Index and Item JSON are also cached when IndexCacheDir is set
+func (r *Runner) Download(ctx context.Context) (err error) {
+	if r.err != nil {
+		return r.err
+	}
+	if r.CacheDir == "" {
+		return fmt.Errorf("Download cache is not configured")
+	}
+	if r.LockfilePath != "" {
+		if r.lockfile, err = project.LoadLockfile(r.LockfilePath); err != nil {
+			return err
+		}
+	}
+	if _err := r.prefetch(ctx); _err != nil {
+		return erron.Errorwf(_err, "Can't fetch item data. Target: %s, Server: %s", r.Source, r.ServerURL)
+	}
+	if err = r.fetch(ctx); err != nil {
+		return err
+	}
+	defer os.RemoveAll(r.tmpdir)
+	if err = r.verifySignature(ctx); err != nil {
+		return err
+	}
+	r.Logger.Printf("Cached %s", r.sourceURL)
+	return nil
+}
+
+// extract unarchives downloaded file into tmpdir. Unarchiving itself is not interruptible; so ctx
+// is checked before and after it
+func (r *Runner) extract(ctx context.Context) (err error) {
 	r.extracted = false
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 	uai, _err := archiver.ByExtension(r.download)
 	if _err != nil {
 		r.Logger.Debugf("Unarchiver can't be determined. %s", _err)
@@ -126,40 +330,78 @@ func (r *Runner) extract() (err error) {
 	if _err = unarchiver.Unarchive(r.download, r.extractDir); _err != nil {
 		return erron.Errorwf(_err, "Failed to unarchive: %s", r.download)
 	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 
 	r.extracted = true
 	return nil
 }
 
-func (r *Runner) locate() (err error) {
+// locate places downloaded or extracted files into DestDir. Files are staged at first and then
+// swapped with existing ones all together; so that previous files are kept on failure
+func (r *Runner) locate(ctx context.Context) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if r.PkgsDir != "" {
+		return r.locateVersioned(ctx)
+	}
+	s, err := newStage(r.DestDir)
+	if err != nil {
+		return err
+	}
+	installed, extra, err := r.stageFiles(ctx, s, r.DestDir)
+	if err == nil {
+		// Last chance to cancel before changing DestDir
+		err = ctx.Err()
+	}
+	if err != nil {
+		s.abort()
+		if extra != nil {
+			extra.abort()
+		}
+		return err
+	}
+	if err = commitStages(append([]*stage{s}, extra.list()...)...); err != nil {
+		return err
+	}
+	installed = append(installed, extra.dests()...)
+	for _, f := range installed {
+		r.Logger.Printf("Installed %s", f)
+	}
+	r.installed = installed
+	return nil
+}
+
+// stageFiles adds files to install into s to be placed in dir; and returns their destinations.
+// Files to be placed out of dir; i.e. non-executable files selected by patterns and auxiliary
+// files, are staged in extra
+func (r *Runner) stageFiles(ctx context.Context, s *stage, dir string) (installed []string, extra *stageSet, err error) {
+	extra = newStageSet()
 	// !ModeExtract OR Unextractable file
 	if !r.extracted {
 		var dest string
 		if r.DestFile == "" {
 			destFile := r.renameFileBySchema(filepath.Base(r.download))
 			if destFile != "" {
-				dest = filepath.Join(r.DestDir, destFile)
+				dest = filepath.Join(dir, destFile)
 			} else {
-				dest = filepath.Join(r.DestDir, filepath.Base(r.download))
+				dest = filepath.Join(dir, filepath.Base(r.download))
 			}
 		} else {
-			dest = filepath.Join(r.DestDir, r.DestFile)
+			dest = filepath.Join(dir, r.DestFile)
 		}
-		if _err := os.Rename(r.download, dest); _err != nil {
-			return erron.Errorwf(_err, "Failed to locate file: %s", dest)
-		}
-		fi, _err := os.Stat(dest)
-		if _err != nil {
-			return erron.Errorwf(_err, "Failed to get file info: %s", dest)
+		if err = s.add(r.download, dest); err != nil {
+			return nil, nil, err
 		}
 		if r.Mode&ModeExecutable != 0 {
 			// Assume downloaded binary is executable
-			if _err = os.Chmod(dest, fi.Mode()|0111); _err != nil {
-				return erron.Errorwf(_err, "Failed to change file mode: %s", dest)
+			if err = s.chmod(dest, func(m os.FileMode) os.FileMode { return m | 0111 }); err != nil {
+				return nil, nil, err
 			}
 		}
-		r.Logger.Printf("Installed %s", dest)
-		return nil
+		return []string{dest}, extra, nil
 	}
 
 	// ModeExtract AND Succeed to Extract
@@ -168,62 +410,278 @@ func (r *Runner) locate() (err error) {
 	// ModeExtract AND !ModeExecutable
 	// Just locate to destination directory
 	if r.Mode&ModeExecutable == 0 {
-		dest := filepath.Join(r.DestDir, filepath.Base(r.extractDir))
-		if _err := os.Rename(r.extractDir, dest); _err != nil {
-			return erron.Errorwf(_err, "Failed to locate content: %s", dest)
+		root, name, err := r.installRoot()
+		if err != nil {
+			return nil, nil, err
 		}
-		r.Logger.Printf("Installed %s", dest)
-		return nil
+		dest := filepath.Join(dir, name)
+		if err = s.add(root, dest); err != nil {
+			return nil, nil, err
+		}
+		return []string{dest}, extra, nil
 	}
 
 	// ModeExtract AND ModeExecutable
-	// Walk through the extracted directory to find and locate executable files
-	installed := []string{}
+	// Walk through the extracted directory to find executable files, files selected by patterns
+	// and auxiliary files
+	sel, err := r.newFileSelector()
+	if err != nil {
+		return nil, nil, err
+	}
+	aux, err := r.newAuxSelector()
+	if err != nil {
+		return nil, nil, err
+	}
+	var executables, others []string
+	auxFiles := make(map[string]AuxRole)
 	err = filepath.Walk(r.extractDir, func(path string, info os.FileInfo, problem error) error {
 		r.Logger.Debugf("Walking in archive: %s", path)
+		if _err := ctx.Err(); _err != nil {
+			return _err
+		}
 		if problem != nil || info.IsDir() {
 			return problem
 		}
-		if isExecutable(info) {
-			var dest string
-			destFile := r.renameFileBySchema(info.Name())
-			if destFile != "" {
-				dest = filepath.Join(r.DestDir, destFile)
-			} else {
-				dest = filepath.Join(r.DestDir, info.Name())
-			}
-			if _err := os.Rename(path, dest); _err != nil {
-				return erron.Errorwf(_err, "Failed to locate file: %s", dest)
-			}
-			r.Logger.Printf("Installed %s", dest)
-			installed = append(installed, dest)
+		rel, _err := filepath.Rel(r.extractDir, path)
+		if _err != nil {
+			return erron.Errorwf(_err, "Failed to get relative path: %s", path)
+		}
+		rel = filepath.ToSlash(rel)
+		if sel.excludes(rel) {
+			return nil
+		}
+		if role, ok := aux.match(rel); ok {
+			auxFiles[path] = role
+			return nil
+		}
+		exec := isExecutable(info)
+		if !sel.selects(rel, exec) {
+			return nil
+		}
+		if exec {
+			executables = append(executables, path)
+		} else {
+			others = append(others, path)
 		}
 		return nil
 	})
-
-	switch len(installed) {
-	case 0:
+	if err != nil {
+		return nil, nil, err
+	}
+	if sel.selective() {
+		for _, p := range sel.unmatched() {
+			r.Logger.Warnf("No file in archive matches %s", p)
+		}
+		if len(executables)+len(others) == 0 {
+			return nil, nil, fmt.Errorf("No file in archive matches: %s", strings.Join(sel.include, ", "))
+		}
+	} else if len(executables) == 0 {
 		r.Logger.Warnf("Archive has no executables. None is installed")
-	case 1:
-		if r.DestFile != "" && r.DestFile != filepath.Base(installed[0]) {
-			dest := filepath.Join(r.DestDir, r.DestFile)
-			if _err := os.Rename(installed[0], dest); _err != nil {
-				return erron.Errorwf(_err, "Failed to locate file: %s", dest)
+	}
+
+	installed = []string{}
+	seen := make(map[string]bool)
+	for _, path := range executables {
+		var dest string
+		if len(executables) == 1 && r.DestFile != "" {
+			dest = filepath.Join(dir, r.DestFile)
+		} else {
+			dest = filepath.Join(dir, r.destName(path))
+		}
+		if err = s.add(path, dest); err != nil {
+			return nil, nil, err
+		}
+		if !seen[dest] {
+			seen[dest] = true
+			installed = append(installed, dest)
+		}
+	}
+
+	for _, path := range others {
+		dest := filepath.Join(r.ShareDir, r.shareName(), r.destName(path))
+		if err = extra.add(path, dest); err != nil {
+			extra.abort()
+			return nil, nil, err
+		}
+	}
+	for _, path := range aux.sorted(auxFiles) {
+		dest, err := r.auxDest(auxFiles[path], path)
+		if err != nil {
+			extra.abort()
+			return nil, nil, err
+		}
+		if dest == "" {
+			continue
+		}
+		if err = extra.add(path, dest); err != nil {
+			extra.abort()
+			return nil, nil, err
+		}
+	}
+	return installed, extra, nil
+}
+
+// destName returns the file name to install extracted file at path as
+func (r *Runner) destName(path string) (name string) {
+	if name = r.renameFileBySchema(filepath.Base(path)); name != "" {
+		return name
+	}
+	return filepath.Base(path)
+}
+
+// shareName returns the name of subdirectory of ShareDir for the item
+func (r *Runner) shareName() (name string) {
+	if r.itemName != "" {
+		return r.itemName
+	}
+	return filepath.Base(r.extractDir)
+}
+
+// record saves the result of installation into the registry file
+func (r *Runner) record() (err error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	reg, err := registry.Load(r.RegistryPath)
+	if err != nil {
+		return err
+	}
+
+	dir, _err := filepath.Abs(r.DestDir)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to get absolute path: %s", r.DestDir)
+	}
+	files := make([]string, 0, len(r.installed))
+	for _, f := range r.installed {
+		abs, _err := filepath.Abs(f)
+		if _err != nil {
+			return erron.Errorwf(_err, "Failed to get absolute path: %s", f)
+		}
+		files = append(files, abs)
+	}
+
+	entry := registry.Entry{
+		Name:            r.itemName,
+		Source:          r.Source,
+		URL:             r.sourceURL,
+		Dir:             dir,
+		DestFile:        r.DestFile,
+		Mode:            int(r.Mode),
+		Checksum:        r.downloadSum,
+		Files:           files,
+		InstalledAt:     time.Now(),
+		Include:         r.Include,
+		Exclude:         r.Exclude,
+		StripComponents: r.StripComponents,
+		Subdir:          r.Subdir,
+	}
+	if entry.Name == "" {
+		entry.Name = r.nameByFiles()
+	}
+	if r.sourceItem != nil {
+		entry.Version = r.sourceItem.Version
+		entry.Server = r.ServerURL.String()
+		entry.Path = r.itemPath
+	}
+	if r.pkgDir != "" {
+		if entry.PkgDir, _err = filepath.Abs(r.pkgDir); _err != nil {
+			return erron.Errorwf(_err, "Failed to get absolute path: %s", r.pkgDir)
+		}
+		if entry.PkgsDir, _err = filepath.Abs(r.PkgsDir); _err != nil {
+			return erron.Errorwf(_err, "Failed to get absolute path: %s", r.PkgsDir)
+		}
+		entry.Pkgs = make(map[string]registry.Pkg)
+		if prev := reg.Get(entry.Name, entry.Dir); prev != nil && prev.PkgDir != "" {
+			if prev.Version != entry.Version {
+				entry.Previous = prev.Version
+			} else {
+				entry.Previous = prev.Previous
+			}
+			for ver, pkg := range prev.Pkgs {
+				entry.Pkgs[ver] = pkg
 			}
-			r.Logger.Printf("Moved %s to %s", installed[0], dest)
 		}
-	default:
-		// Do nothing
+		entry.Pkgs[entry.Version] = registry.Pkg{URL: entry.URL, Checksum: entry.Checksum}
+	}
+
+	reg.Add(entry)
+	if err = reg.Save(); err != nil {
+		return err
+	}
+	r.Logger.Debugf("Recorded installation of %s into %s", entry.Name, reg.Path())
+	return nil
+}
+
+// updateLockfile pins resolved URL and checksum of the item for running platform
+func (r *Runner) updateLockfile() (err error) {
+	if r.SkipVerify {
+		r.Logger.Warnf("Checksum verification is skipped. Skip updating Lockfile")
+		return nil
+	}
+	if r.downloadSum == nil {
+		r.Logger.Warnf("Checksum is not verified. Skip updating Lockfile")
+		return nil
+	}
+	sum, _, kind := r.downloadSum.GetSumAndHasher()
+	artifact := project.LockedArtifact{URL: r.sourceURL, Algorithm: kind.String(), Digest: sum}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	// Reload not to lose updates by other Runners
+	if r.lockfile, err = project.LoadLockfile(r.LockfilePath); err != nil {
+		return err
 	}
 
-	return err
+	locked := r.lockfile.Find(r.itemName)
+	if locked == nil || locked.Version != r.sourceItem.Version {
+		locked = &project.LockedItem{Name: r.itemName, Version: r.sourceItem.Version}
+	}
+	if locked.Platforms == nil {
+		locked.Platforms = make(map[string]project.LockedArtifact)
+	}
+	platform := project.Platform(r.os, r.arch)
+	if prev, ok := locked.Platforms[platform]; ok && prev == artifact && locked.Path == r.itemPath {
+		return nil
+	}
+	locked.Server = r.ServerURL.String()
+	locked.Path = r.itemPath
+	locked.Platforms[platform] = artifact
+	r.lockfile.Put(*locked)
+	if err = r.lockfile.Save(); err != nil {
+		return err
+	}
+	r.Logger.Infof("Locked %s@%s for %s", r.itemName, r.sourceItem.Version, platform)
+	return nil
 }
 
-func (r *Runner) getClient() (c *client.Client) {
+// nameByFiles determines the name of item installed from URL
+func (r *Runner) nameByFiles() (name string) {
+	if r.DestFile != "" {
+		return r.DestFile
+	}
+	if len(r.installed) > 0 {
+		return filepath.Base(r.installed[0])
+	}
+	return filepath.Base(r.download)
+}
+
+func (r *Runner) getClient() (c *client.Client, err error) {
 	if r.clt == nil {
-		r.clt = client.NewClient(r.ServerURL, r.Logger)
+		if r.clt, err = newClient(r.ServerURL, r.ConfigPath, r.HTTPOptions, r.Logger); err != nil {
+			return nil, err
+		}
+		r.clt.SetCacheDir(r.IndexCacheDir)
+		r.clt.SetOffline(r.Offline)
 	}
-	return r.clt
+	return r.clt, nil
+}
+
+func (r *Runner) getHTTPClient() (hc *http.Client, err error) {
+	if r.hc == nil {
+		if r.hc, err = http.NewClient(r.HTTPOptions); err != nil {
+			return nil, err
+		}
+	}
+	return r.hc, nil
 }
 
 func (r *Runner) renameFileBySchema(orig string) (tobe string) {
@@ -231,7 +689,11 @@ func (r *Runner) renameFileBySchema(orig string) (tobe string) {
 		return ""
 	}
 	param := item.FormatParam{OS: r.os, Arch: r.arch}
-	tobe = r.sourceItem.ConvertFileName(orig, param)
+	tobe, err := r.sourceItem.ConvertFileName(orig, param)
+	if err != nil {
+		r.Logger.Warnf("Can't rename %s. %v", orig, err)
+		return ""
+	}
 	if tobe != "" {
 		r.Logger.Infof("Rename: %s => %s", orig, tobe)
 	}
@@ -0,0 +1,215 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/binqry/binq/install/cache"
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/schema/project"
+	"github.com/progrhyme/go-lv"
+)
+
+func TestLockfile(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(map[string]string{"foo": testItemJSONFormat}))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	lockPath := filepath.Join(tmpdir, "binq.lock")
+	regPath := filepath.Join(tmpdir, "installed.json")
+	log := &strings.Builder{}
+	opt := RunOption{
+		Source:       "foo@0.1.0",
+		DestDir:      tmpdir,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		ServerURL:    ts.URL,
+		RegistryPath: regPath,
+		LockfilePath: lockPath,
+	}
+	if err := Run(opt); err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+
+	lf, err := project.LoadLockfile(lockPath)
+	if err != nil {
+		t.Fatalf("Failed to load Lockfile. %v", err)
+	}
+	locked := lf.Find("foo")
+	if locked == nil || locked.Version != "0.1.0" || locked.Path != "foo" {
+		t.Fatalf("Locked item mismatch. Got: %+v", locked)
+	}
+	artifact, ok := locked.Platforms[project.Platform(runtime.GOOS, runtime.GOARCH)]
+	if !ok || artifact.Algorithm != "sha256" || artifact.Digest == "" {
+		t.Fatalf("Locked artifact mismatch. Got: %+v", locked.Platforms)
+	}
+
+	// Locked version is installed when version is not specified
+	opt.Source = "foo"
+	if err = Run(opt); err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	if entry := reg.Get("foo", tmpdir); entry == nil || entry.Version != "0.1.0" {
+		t.Errorf("Installed version mismatch. Want: 0.1.0, Got: %+v", entry)
+	}
+
+	// Installation fails when content differs from Lockfile
+	artifact.Digest = strings.Repeat("0", len(artifact.Digest))
+	locked.Platforms[project.Platform(runtime.GOOS, runtime.GOARCH)] = artifact
+	lf.Put(*locked)
+	if err = lf.Save(); err != nil {
+		t.Fatalf("Failed to save Lockfile. %v", err)
+	}
+	if err = Run(opt); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Error mismatch. Want: %v, Got: %v", ErrChecksumMismatch, err)
+	}
+
+	// Content installed without verification is not pinned
+	opt.LockfilePath = filepath.Join(tmpdir, "insecure.lock")
+	opt.SkipVerify = true
+	if err = Run(opt); err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+	if lf, err = project.LoadLockfile(opt.LockfilePath); err != nil {
+		t.Fatalf("Failed to load Lockfile. %v", err)
+	}
+	if locked := lf.Find("foo"); locked != nil {
+		t.Errorf("Item is locked though checksum is not verified. Got: %+v", locked)
+	}
+}
+
+func TestRunConcurrently(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(nil))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	regPath := filepath.Join(tmpdir, "registry", "installed.json")
+	cacheDir := filepath.Join(tmpdir, "cache")
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	errs := make([]error, len(names))
+	logs := make([]strings.Builder, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = Run(RunOption{
+				Source:       ts.URL + "/download/" + name,
+				DestDir:      tmpdir,
+				Output:       &logs[i],
+				LogLevel:     lv.LNotice,
+				RegistryPath: regPath,
+				CacheDir:     cacheDir,
+			})
+		}(i, name)
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		if errs[i] != nil {
+			t.Errorf("Install of %s failed. %v\nLog: %s", name, errs[i], logs[i].String())
+		}
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	c, err := cache.Open(cacheDir)
+	if err != nil {
+		t.Fatalf("Failed to open cache. %v", err)
+	}
+	for _, name := range names {
+		if entries := reg.Find(name); len(entries) != 1 {
+			t.Errorf("Registry entry of %s is lost. Registry: %s", name, reg)
+		}
+		if c.LookupURL(ts.URL+"/download/"+name) == nil {
+			t.Errorf("Cache entry of %s is lost. Cache: %s", name, c)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(nil))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	log := &strings.Builder{}
+	logger := lv.New(log, lv.LInfo, 0)
+	if err := New(RunOption{Source: ts.URL + "/download/foo", DestDir: tmpdir, Logger: logger}).
+		Run(context.Background()); err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+	if !strings.Contains(log.String(), "Installed "+filepath.Join(tmpdir, "foo")) {
+		t.Errorf("Injected logger is not used. Log: %s", log)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := New(RunOption{Source: ts.URL + "/download/bar", DestDir: tmpdir, Logger: logger}).Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Error mismatch. Want: %v, Got: %v", context.Canceled, err)
+	}
+	if _, err = os.Stat(filepath.Join(tmpdir, "bar")); !os.IsNotExist(err) {
+		t.Errorf("File is installed though context is canceled")
+	}
+
+	if err = New(RunOption{Source: "foo", ServerURL: "://server", Logger: logger}).
+		Run(context.Background()); err == nil {
+		t.Errorf("Expected error for invalid server URL but got nil")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	started := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1024")
+		w.Write([]byte("#!/bin/sh\n"))
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	// Temporary files are created under $TMPDIR
+	tmpRoot := filepath.Join(tmpdir, "tmp")
+	if err := os.Mkdir(tmpRoot, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TMPDIR", tmpRoot)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	log := &strings.Builder{}
+	err := New(RunOption{
+		Source:   ts.URL + "/download/foo",
+		DestDir:  tmpdir,
+		Output:   log,
+		LogLevel: lv.LNotice,
+	}).Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Error mismatch. Want: %v, Got: %v\nLog: %s", context.Canceled, err, log)
+	}
+	if _, err = os.Stat(filepath.Join(tmpdir, "foo")); !os.IsNotExist(err) {
+		t.Errorf("File is installed though installation is canceled")
+	}
+	if files, _ := ioutil.ReadDir(tmpRoot); len(files) > 0 {
+		t.Errorf("Temporary files remain: %s", files[0].Name())
+	}
+}
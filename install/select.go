@@ -0,0 +1,99 @@
+package install
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/binqry/binq/internal/xdg"
+	"github.com/binqry/binq/schema/item"
+)
+
+// DefaultShareDir returns the directory for non-executable files under $XDG_DATA_HOME
+func DefaultShareDir() (path string) {
+	return filepath.Join(xdg.DataDir(), "share")
+}
+
+// fileSelector decides which files in extracted archive are installed.
+// When include is empty, all executables are installed except for excluded ones
+type fileSelector struct {
+	include, exclude []string
+	matched          map[string]bool
+}
+
+// validatePatterns returns error when any of patterns is malformed
+func validatePatterns(patterns []string) (err error) {
+	for _, p := range patterns {
+		if _, _err := path.Match(p, ""); _err != nil {
+			return fmt.Errorf("Malformed glob pattern: %s", p)
+		}
+	}
+	return nil
+}
+
+// newFileSelector returns fileSelector by Include and Exclude of r, or "files" of the item
+func (r *Runner) newFileSelector() (sel *fileSelector, err error) {
+	sel = &fileSelector{include: r.Include, exclude: r.Exclude, matched: make(map[string]bool)}
+	if len(sel.include) == 0 && r.sourceItem != nil {
+		param := item.FormatParam{OS: r.os, Arch: r.arch}
+		if sel.include, err = r.sourceItem.GetFilePatterns(param); err != nil {
+			return nil, err
+		}
+		if err = validatePatterns(sel.include); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+// selective reports whether files are selected by patterns rather than by executable bits
+func (sel *fileSelector) selective() bool {
+	return len(sel.include) > 0
+}
+
+// excludes reports whether the file at rel is excluded. rel is slash-separated path from the root
+// of archive
+func (sel *fileSelector) excludes(rel string) bool {
+	for _, p := range sel.exclude {
+		if matchFile(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// selects reports whether the file at rel which is not excluded is installed
+func (sel *fileSelector) selects(rel string, executable bool) bool {
+	if !sel.selective() {
+		return executable
+	}
+	for _, p := range sel.include {
+		if matchFile(p, rel) {
+			sel.matched[p] = true
+			return true
+		}
+	}
+	return false
+}
+
+// unmatched returns include patterns which matched no file
+func (sel *fileSelector) unmatched() (patterns []string) {
+	for _, p := range sel.include {
+		if !sel.matched[p] {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// matchFile reports whether rel matches pattern. Pattern without "/" is matched against the base
+// name of rel
+func matchFile(pattern, rel string) bool {
+	target := rel
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(rel)
+	}
+	ok, _ := path.Match(pattern, target)
+	return ok
+}
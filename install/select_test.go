@@ -0,0 +1,109 @@
+package install
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/progrhyme/go-lv"
+)
+
+// newTestArchive returns zip archive which has executables, completions, man page and other files
+// under "pkg/"
+func newTestArchive(t *testing.T) (b []byte) {
+	return newZipArchive(t, []testArchiveFile{
+		{"pkg/bin/tool", 0755},
+		{"pkg/bin/tool-test", 0755},
+		{"pkg/libexec/helper", 0755},
+		{"pkg/LICENSE", 0644},
+		{"pkg/README", 0644},
+		{"pkg/completions/tool.bash", 0644},
+		{"pkg/completions/_tool", 0644},
+		{"pkg/completions/tool.fish", 0644},
+		{"pkg/man/tool.1", 0644},
+	})
+}
+
+func TestSelectFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Executable bits are not used on Windows")
+	}
+	archive := newTestArchive(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		include, exclude []string
+		bin, share       []string
+		fail             bool
+	}{
+		{bin: []string{"helper", "tool", "tool-test"}},
+		{exclude: []string{"*-test", "libexec/*"}, bin: []string{"helper", "tool"}},
+		{
+			include: []string{"*/bin/*", "LICENSE"}, exclude: []string{"*-test"},
+			bin: []string{"tool"}, share: []string{"LICENSE"},
+		},
+		{include: []string{"no-such-file"}, fail: true},
+	}
+	for i, c := range cases {
+		tmpdir := t.TempDir()
+		binDir := filepath.Join(tmpdir, "bin")
+		shareDir := filepath.Join(tmpdir, "share")
+		os.Mkdir(binDir, 0755)
+		regPath := filepath.Join(tmpdir, "installed.json")
+		log := &strings.Builder{}
+
+		err := Run(RunOption{
+			Source:       ts.URL + "/pkg.zip",
+			DestDir:      binDir,
+			Output:       log,
+			LogLevel:     lv.LNotice,
+			RegistryPath: regPath,
+			Include:      c.include,
+			Exclude:      c.exclude,
+			ShareDir:     shareDir,
+		})
+		if c.fail {
+			if err == nil {
+				t.Errorf("[%d] Install should fail", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%d] Install failed. %v\nLog: %s", i, err, log)
+		}
+
+		for dir, want := range map[string][]string{binDir: c.bin, filepath.Join(shareDir, "pkg"): c.share} {
+			var got []string
+			if infos, err := ioutil.ReadDir(dir); err == nil {
+				for _, fi := range infos {
+					got = append(got, fi.Name())
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("[%d] Files in %s mismatch. Want: %v, Got: %v", i, dir, want, got)
+			}
+		}
+
+		if _, err = Uninstall(UninstallOption{
+			Name: "tool", Output: log, LogLevel: lv.LNotice, RegistryPath: regPath,
+		}); err != nil {
+			t.Errorf("[%d] Uninstall failed. %v\nLog: %s", i, err, log)
+		}
+		if _, err = os.Stat(filepath.Join(shareDir, "pkg", "LICENSE")); !os.IsNotExist(err) {
+			t.Errorf("[%d] Shared file remains after uninstall", i)
+		}
+	}
+
+	if err := New(RunOption{Source: ts.URL + "/pkg.zip", Include: []string{"["}}).Run(context.Background()); err == nil {
+		t.Errorf("Malformed pattern should be an error")
+	}
+}
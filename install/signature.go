@@ -0,0 +1,54 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/signature"
+	"github.com/binqry/binq/schema/item"
+)
+
+// verifySignature verifies detached signature of downloaded file with the public key in Item
+// Manifest. It fails when signature is declared but can't be verified for any reason
+func (r *Runner) verifySignature(ctx context.Context) (err error) {
+	if r.sourceItem == nil {
+		return nil
+	}
+	sigURL, err := r.sourceItem.GetSignatureURL(item.FormatParam{OS: r.os, Arch: r.arch})
+	if err != nil || sigURL == "" {
+		return err
+	}
+	if r.SkipSignature {
+		r.Logger.Warnf("Skip signature verification. Signature: %s", sigURL)
+		return nil
+	}
+	if r.sourceItem.PublicKey == "" {
+		return fmt.Errorf("Public key to verify signature is not provided. Signature: %s", sigURL)
+	}
+	pk, err := signature.ParsePublicKey(r.sourceItem.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	clt, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	sig, err := clt.GetFileContext(ctx, sigURL)
+	if err != nil {
+		return err
+	}
+
+	f, _err := os.Open(r.download)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to open file: %s", r.download)
+	}
+	defer f.Close()
+	if _err = pk.VerifyReader(f, sig); _err != nil {
+		return erron.Errorwf(_err, "File: %s, Signature: %s", r.download, sigURL)
+	}
+	r.Logger.Infof("Signature is OK")
+	return nil
+}
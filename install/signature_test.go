@@ -0,0 +1,100 @@
+package install
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/progrhyme/go-lv"
+)
+
+// testSignedItemJSONFormat represents an item whose files have detached signatures
+const testSignedItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/download/%s-{{.Version}}",
+    "signature-url-format": "http://%s/sig/%s-{{.Version}}.sig",
+    "public-key": "%s"
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0"
+    }
+  ]
+}`
+
+// testSignKey is the private key to sign files on test server
+var testSignKey = ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+// handleSignedItems makes mux serve items "signed", "forged" and "keyless" which have detached
+// signatures. Signature of "forged" does not match the content; and "keyless" has no public key
+func handleSignedItems(mux *http.ServeMux) {
+	pubKey := base64.StdEncoding.EncodeToString(testSignKey.Public().(ed25519.PublicKey))
+	for name, key := range map[string]string{"signed": pubKey, "forged": pubKey, "keyless": ""} {
+		name, key := name, key
+		mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, testSignedItemJSONFormat, r.Host, name, r.Host, name, key)
+		})
+	}
+	mux.HandleFunc("/sig/", func(w http.ResponseWriter, r *http.Request) {
+		content := testContent
+		if strings.HasPrefix(path.Base(r.URL.Path), "forged") {
+			content = "#!/bin/sh\necho forged\n"
+		}
+		w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(testSignKey, []byte(content)))))
+	})
+}
+
+func TestVerifySignature(t *testing.T) {
+	mux := newTestMux(nil)
+	handleSignedItems(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	log := &strings.Builder{}
+	testCases := []struct {
+		source                    string
+		skipVerify, skipSignature bool
+		success                   bool
+		want                      error
+	}{
+		{source: "signed", success: true},
+		{source: "forged", want: ErrSignatureInvalid},
+		// Skipping checksum verification does not skip signature verification
+		{source: "forged", skipVerify: true, want: ErrSignatureInvalid},
+		{source: "forged", skipSignature: true, success: true},
+		{source: "keyless"},
+	}
+	for i, tc := range testCases {
+		dir := filepath.Join(tmpdir, fmt.Sprint(i))
+		os.Mkdir(dir, 0755)
+		err := Run(RunOption{
+			Source:        tc.source,
+			DestDir:       dir,
+			Output:        log,
+			LogLevel:      lv.LNotice,
+			ServerURL:     ts.URL,
+			SkipVerify:    tc.skipVerify,
+			SkipSignature: tc.skipSignature,
+		})
+		if tc.success != (err == nil) || (tc.want != nil && !errors.Is(err, tc.want)) {
+			t.Errorf("[%d] Unexpected result. Want: %v, Got: %v", i, tc.want, err)
+		}
+		files, _ := ioutil.ReadDir(dir)
+		if installed := len(files) > 0; installed != tc.success {
+			t.Errorf("[%d] Installed files mismatch. Got: %v", i, files)
+		}
+	}
+}
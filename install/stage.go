@@ -0,0 +1,290 @@
+package install
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/binqry/binq/internal/erron"
+)
+
+// stagePrefix is the prefix of staging directory which is hidden in destination directory
+const stagePrefix = ".binq-stage."
+
+// stage places files into a destination directory all together.
+// New files are moved into a hidden staging directory in the destination directory at first; and
+// then swapped with existing ones by rename on commit. When any of them fails, the previous files
+// are restored. Because staging directory is on the same filesystem as destinations, the swap
+// does not suffer from cross-device rename
+type stage struct {
+	dir     string
+	entries []*stagedFile
+	index   map[string]int
+}
+
+type stagedFile struct {
+	dest, staged, backup string
+	swapped              bool
+}
+
+// newStage creates staging directory in destDir. abort or commit should be called after all
+// files are added to remove it.
+// Empty destDir means the current directory; not os.TempDir which ioutil.TempDir falls back to
+func newStage(destDir string) (s *stage, err error) {
+	abs, _err := filepath.Abs(destDir)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to get absolute path: %s", destDir)
+	}
+	dir, _err := ioutil.TempDir(abs, stagePrefix)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to create staging directory in %s", destDir)
+	}
+	return &stage{dir: dir, index: make(map[string]int)}, nil
+}
+
+// add moves src into staging area to be placed at dest. src can be a file or a directory.
+// When the same dest is added twice, the latter one is used
+func (s *stage) add(src, dest string) (err error) {
+	return s.put(dest, func(staged string) error { return moveFile(src, staged) })
+}
+
+// addSymlink stages symbolic link to target to be placed at dest
+func (s *stage) addSymlink(target, dest string) (err error) {
+	return s.put(dest, func(staged string) error {
+		if _err := os.Symlink(target, staged); _err != nil {
+			return erron.Errorwf(_err, "Failed to create symbolic link: %s", dest)
+		}
+		return nil
+	})
+}
+
+// put creates the file for dest in staging area by create
+func (s *stage) put(dest string, create func(staged string) error) (err error) {
+	if i, ok := s.index[dest]; ok {
+		staged := s.entries[i].staged
+		os.RemoveAll(staged)
+		return create(staged)
+	}
+	id := strconv.Itoa(len(s.entries))
+	entry := &stagedFile{
+		dest:   dest,
+		staged: filepath.Join(s.dir, "new-"+id),
+		backup: filepath.Join(s.dir, "old-"+id),
+	}
+	if err = create(entry.staged); err != nil {
+		return err
+	}
+	s.index[dest] = len(s.entries)
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// chmod changes file mode of the file staged for dest
+func (s *stage) chmod(dest string, fn func(os.FileMode) os.FileMode) (err error) {
+	i, ok := s.index[dest]
+	if !ok {
+		return fmt.Errorf("File is not staged: %s", dest)
+	}
+	path := s.entries[i].staged
+	fi, _err := os.Stat(path)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to get file info: %s", path)
+	}
+	if _err = os.Chmod(path, fn(fi.Mode())); _err != nil {
+		return erron.Errorwf(_err, "Failed to change file mode: %s", dest)
+	}
+	return nil
+}
+
+// dests returns destinations of staged files in order of addition
+func (s *stage) dests() (dests []string) {
+	for _, e := range s.entries {
+		dests = append(dests, e.dest)
+	}
+	return dests
+}
+
+// commit swaps staged files with existing ones. On failure, all destinations are rolled back
+func (s *stage) commit() (err error) {
+	return commitStages(s)
+}
+
+// commitStages commits stages in different directories all together. On failure, destinations
+// of all of them are rolled back. nil stages are ignored
+func commitStages(stages ...*stage) (err error) {
+	for _, s := range stages {
+		defer s.abort()
+	}
+	for i, s := range stages {
+		if s == nil {
+			continue
+		}
+		for _, e := range s.entries {
+			if err = e.swap(); err != nil {
+				for j := i; j >= 0; j-- {
+					if stages[j] != nil {
+						stages[j].rollback()
+					}
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// abort discards staged files. It does nothing on nil
+func (s *stage) abort() {
+	if s == nil {
+		return
+	}
+	os.RemoveAll(s.dir)
+}
+
+// stageSet holds stages for files placed in different directories
+type stageSet struct {
+	stages map[string]*stage
+	order  []*stage
+}
+
+func newStageSet() (ss *stageSet) {
+	return &stageSet{stages: make(map[string]*stage)}
+}
+
+// add moves src into the stage for the directory of dest. The directory is created when it
+// does not exist
+func (ss *stageSet) add(src, dest string) (err error) {
+	dir := filepath.Dir(dest)
+	s, ok := ss.stages[dir]
+	if !ok {
+		if _err := os.MkdirAll(dir, 0755); _err != nil {
+			return erron.Errorwf(_err, "Failed to make directory: %s", dir)
+		}
+		if s, err = newStage(dir); err != nil {
+			return err
+		}
+		ss.stages[dir] = s
+		ss.order = append(ss.order, s)
+	}
+	return s.add(src, dest)
+}
+
+// list returns stages in ss
+func (ss *stageSet) list() (stages []*stage) {
+	return ss.order
+}
+
+// dests returns destinations of files staged in ss
+func (ss *stageSet) dests() (dests []string) {
+	for _, s := range ss.order {
+		dests = append(dests, s.dests()...)
+	}
+	return dests
+}
+
+// abort discards files staged in ss
+func (ss *stageSet) abort() {
+	for _, s := range ss.order {
+		s.abort()
+	}
+}
+
+func (s *stage) rollback() {
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		s.entries[i].restore()
+	}
+}
+
+func (e *stagedFile) swap() (err error) {
+	if _, _err := os.Lstat(e.dest); _err == nil {
+		if _err = os.Rename(e.dest, e.backup); _err != nil {
+			return erron.Errorwf(_err, "Failed to back up file: %s", e.dest)
+		}
+	}
+	if _err := os.Rename(e.staged, e.dest); _err != nil {
+		// Put back the backup at once. Others are restored by rollback
+		os.Rename(e.backup, e.dest)
+		return erron.Errorwf(_err, "Failed to locate file: %s", e.dest)
+	}
+	e.swapped = true
+	return nil
+}
+
+func (e *stagedFile) restore() {
+	if !e.swapped {
+		return
+	}
+	os.RemoveAll(e.dest)
+	if _, err := os.Lstat(e.backup); err == nil {
+		os.Rename(e.backup, e.dest)
+	}
+	e.swapped = false
+}
+
+// moveFile moves src to dest by rename. When rename fails; e.g. src and dest are on different
+// filesystems, src is copied to dest and removed
+func moveFile(src, dest string) (err error) {
+	if _err := os.Rename(src, dest); _err == nil {
+		return nil
+	}
+	if err = copyTree(src, dest); err != nil {
+		os.RemoveAll(dest)
+		return err
+	}
+	os.RemoveAll(src)
+	return nil
+}
+
+// copyTree copies file, directory or symbolic link src to dest preserving file modes
+func copyTree(src, dest string) (err error) {
+	fi, _err := os.Lstat(src)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to get file info: %s", src)
+	}
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		link, _err := os.Readlink(src)
+		if _err != nil {
+			return erron.Errorwf(_err, "Failed to read symbolic link: %s", src)
+		}
+		if _err = os.Symlink(link, dest); _err != nil {
+			return erron.Errorwf(_err, "Failed to create symbolic link: %s", dest)
+		}
+		return nil
+	case fi.IsDir():
+		if _err = os.Mkdir(dest, fi.Mode().Perm()); _err != nil {
+			return erron.Errorwf(_err, "Failed to make directory: %s", dest)
+		}
+		children, _err := ioutil.ReadDir(src)
+		if _err != nil {
+			return erron.Errorwf(_err, "Failed to read directory: %s", src)
+		}
+		for _, c := range children {
+			if err = copyTree(filepath.Join(src, c.Name()), filepath.Join(dest, c.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	in, _err := os.Open(src)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to open file: %s", src)
+	}
+	defer in.Close()
+	out, _err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to open file: %s", dest)
+	}
+	if _, _err = io.Copy(out, in); _err != nil {
+		out.Close()
+		return erron.Errorwf(_err, "Failed to copy file: %s", src)
+	}
+	if _err = out.Close(); _err != nil {
+		return erron.Errorwf(_err, "Failed to write file: %s", dest)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package install
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/progrhyme/go-lv"
+)
+
+func TestStage(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	write := func(path, content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(path string) string {
+		b, _ := ioutil.ReadFile(path)
+		return string(b)
+	}
+	destDir := filepath.Join(tmpdir, "bin")
+	srcDir := filepath.Join(tmpdir, "src")
+	for _, dir := range []string{destDir, srcDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertClean := func(label string) {
+		if files, _ := filepath.Glob(filepath.Join(destDir, ".binq-stage.*")); len(files) > 0 {
+			t.Errorf("[%s] Staging directory remains: %v", label, files)
+		}
+	}
+
+	// Failure in the middle rolls back the files already swapped
+	write(filepath.Join(destDir, "foo"), "old foo")
+	write(filepath.Join(srcDir, "foo"), "new foo")
+	write(filepath.Join(srcDir, "bar"), "new bar")
+	s, err := newStage(destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.add(filepath.Join(srcDir, "foo"), filepath.Join(destDir, "foo")); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.add(filepath.Join(srcDir, "bar"), filepath.Join(destDir, "no-such-dir", "bar")); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.commit(); err == nil {
+		t.Errorf("Expected error on commit but got nil")
+	}
+	if got := read(filepath.Join(destDir, "foo")); got != "old foo" {
+		t.Errorf("Previous file is not restored. Got: %q", got)
+	}
+	assertClean("rollback")
+
+	// Commit replaces all files
+	write(filepath.Join(srcDir, "foo"), "new foo")
+	write(filepath.Join(srcDir, "bar"), "new bar")
+	if s, err = newStage(destDir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		if err = s.add(filepath.Join(srcDir, name), filepath.Join(destDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.commit(); err != nil {
+		t.Fatalf("Commit failed. %v", err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		if got := read(filepath.Join(destDir, name)); got != "new "+name {
+			t.Errorf("File is not replaced: %s. Got: %q", name, got)
+		}
+	}
+	assertClean("commit")
+}
+
+func TestStageInCurrentDir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Staging directory must be on the same filesystem as destinations
+	s, err := newStage("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(s.dir) != cwd {
+		t.Errorf("Staging directory is not in current directory. Got: %s", s.dir)
+	}
+	s.abort()
+
+	ts := httptest.NewServer(newTestMux(nil))
+	defer ts.Close()
+	log := &strings.Builder{}
+	err = Run(RunOption{Source: ts.URL + "/download/foo", DestDir: "", Output: log, LogLevel: lv.LNotice})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+	if _, err = os.Stat(filepath.Join(cwd, "foo")); err != nil {
+		t.Errorf("Installed file not found in current directory. %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(cwd, stagePrefix+"*")); len(files) > 0 {
+		t.Errorf("Staging directory remains: %v", files)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	tmpdir := t.TempDir()
+
+	src := filepath.Join(tmpdir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "sub", "exe"), []byte(testContent), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub/exe", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(tmpdir, "dest")
+	if err := copyTree(src, dest); err != nil {
+		t.Fatalf("Copy failed. %v", err)
+	}
+	fi, err := os.Stat(filepath.Join(dest, "sub", "exe"))
+	if err != nil {
+		t.Fatalf("Copied file not found. %v", err)
+	}
+	if fi.Mode().Perm() != 0755 {
+		t.Errorf("File mode mismatch. Want: %o, Got: %o", 0755, fi.Mode().Perm())
+	}
+	if link, err := os.Readlink(filepath.Join(dest, "link")); err != nil || link != "sub/exe" {
+		t.Errorf("Symbolic link mismatch. Got: %s, Error: %v", link, err)
+	}
+}
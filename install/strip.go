@@ -0,0 +1,153 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/schema/item"
+)
+
+// validateSubdir returns error when subdir points outside of archive
+func validateSubdir(subdir string) (err error) {
+	if subdir == "" {
+		return nil
+	}
+	cleaned := path.Clean(filepath.ToSlash(subdir))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf("Subdirectory must be a relative path in archive: %s", subdir)
+	}
+	return nil
+}
+
+// installRoot returns the directory in extracted archive to install without executables and the
+// name to place it as in DestDir. Subdir is applied at first and then StripComponents. Settings
+// of the Runner take precedence over the ones in Item Manifest
+func (r *Runner) installRoot() (root, name string, err error) {
+	subdir, strip := r.Subdir, 0
+	if r.StripComponents != nil {
+		strip = *r.StripComponents
+	}
+	if r.sourceItem != nil {
+		if subdir == "" {
+			param := item.FormatParam{OS: r.os, Arch: r.arch}
+			if subdir, err = r.sourceItem.GetSubdir(param); err != nil {
+				return "", "", err
+			}
+			if err = validateSubdir(subdir); err != nil {
+				return "", "", err
+			}
+		}
+		if r.StripComponents == nil && r.sourceItem.StripComponents != nil {
+			strip = *r.sourceItem.StripComponents
+		}
+	}
+	if subdir == "" && strip <= 0 {
+		return r.extractDir, filepath.Base(r.extractDir), nil
+	}
+
+	root = r.extractDir
+	if subdir != "" {
+		root = filepath.Join(r.extractDir, filepath.FromSlash(subdir))
+		if fi, _err := os.Stat(root); _err != nil || !fi.IsDir() {
+			return "", "", fmt.Errorf("Subdirectory is not found in archive: %s", subdir)
+		}
+		r.Logger.Infof("Install subdirectory %s in archive", subdir)
+	}
+	if strip > 0 {
+		if root, err = stripComponents(root, strip, r.tmpdir); err != nil {
+			return "", "", err
+		}
+		r.Logger.Infof("Stripped %d leading components of paths in archive", strip)
+	}
+
+	switch {
+	case r.DestFile != "":
+		name = r.DestFile
+	case r.itemName != "":
+		name = r.itemName
+	default:
+		name = filepath.Base(r.extractDir)
+	}
+	return root, name, nil
+}
+
+// stripComponents moves files in dir whose paths have more than n components into a new
+// directory in workDir with n leading components removed; like "tar --strip-components".
+// Files with fewer components are dropped
+func stripComponents(dir string, n int, workDir string) (stripped string, err error) {
+	entries, err := collectAtDepth(dir, n)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("Nothing is left after stripping %d components of paths in archive", n)
+	}
+	stripped, _err := ioutil.TempDir(workDir, "strip.")
+	if _err != nil {
+		return "", erron.Errorwf(_err, "Failed to create directory in %s", workDir)
+	}
+	for _, e := range entries {
+		if err = mergeMove(e, filepath.Join(stripped, filepath.Base(e))); err != nil {
+			return "", err
+		}
+	}
+	return stripped, nil
+}
+
+// collectAtDepth returns paths of entries in dir after n leading components are stripped
+func collectAtDepth(dir string, n int) (paths []string, err error) {
+	children, _err := ioutil.ReadDir(dir)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to read directory: %s", dir)
+	}
+	for _, c := range children {
+		path := filepath.Join(dir, c.Name())
+		if n <= 0 {
+			paths = append(paths, path)
+			continue
+		}
+		if !c.IsDir() {
+			continue
+		}
+		sub, err := collectAtDepth(path, n-1)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, sub...)
+	}
+	return paths, nil
+}
+
+// mergeMove moves src to dest. When both are directories, their contents are merged; and
+// otherwise src replaces dest
+func mergeMove(src, dest string) (err error) {
+	dfi, _err := os.Lstat(dest)
+	if os.IsNotExist(_err) {
+		return moveFile(src, dest)
+	}
+	sfi, _err := os.Lstat(src)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to get file info: %s", src)
+	}
+	if !sfi.IsDir() || !dfi.IsDir() {
+		if _err = os.RemoveAll(dest); _err != nil {
+			return erron.Errorwf(_err, "Failed to remove: %s", dest)
+		}
+		return moveFile(src, dest)
+	}
+	children, _err := ioutil.ReadDir(src)
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to read directory: %s", src)
+	}
+	for _, c := range children {
+		if err = mergeMove(filepath.Join(src, c.Name()), filepath.Join(dest, c.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package install
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/progrhyme/go-lv"
+)
+
+func TestStripComponents(t *testing.T) {
+	archive := newZipArchive(t, []testArchiveFile{
+		{"go/bin/go", 0755},
+		{"go/lib/time/zoneinfo.zip", 0644},
+		{"extra/bin/tool", 0755},
+		{"README", 0644},
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		strip          *int
+		subdir, file   string
+		want, notFound []string
+		fail           bool
+	}{
+		{want: []string{"sdk/go/bin/go", "sdk/README"}},
+		{
+			strip: intRef(1), file: "go",
+			want:     []string{"go/bin/go", "go/bin/tool", "go/lib/time/zoneinfo.zip"},
+			notFound: []string{"go/README", "sdk"},
+		},
+		{subdir: "go/lib", want: []string{"sdk/time/zoneinfo.zip"}, notFound: []string{"sdk/bin"}},
+		{subdir: "go", strip: intRef(1), file: "lib", want: []string{"lib/go", "lib/time/zoneinfo.zip"}},
+		{strip: intRef(4), fail: true},
+		{subdir: "no-such-dir", fail: true},
+		{subdir: "../go", fail: true},
+		{strip: intRef(-1), fail: true},
+	}
+	for i, c := range cases {
+		tmpdir := t.TempDir()
+		log := &strings.Builder{}
+
+		err := Run(RunOption{
+			Mode:            ModeExtract,
+			Source:          ts.URL + "/sdk.zip",
+			DestDir:         tmpdir,
+			DestFile:        c.file,
+			Output:          log,
+			LogLevel:        lv.LNotice,
+			StripComponents: c.strip,
+			Subdir:          c.subdir,
+		})
+		if c.fail {
+			if err == nil {
+				t.Errorf("[%d] Install should fail", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%d] Install failed. %v\nLog: %s", i, err, log)
+		}
+		for _, f := range c.want {
+			if _, err = os.Stat(filepath.Join(tmpdir, f)); err != nil {
+				t.Errorf("[%d] Installed file not found: %s. %v", i, f, err)
+			}
+		}
+		for _, f := range c.notFound {
+			if _, err = os.Stat(filepath.Join(tmpdir, f)); !os.IsNotExist(err) {
+				t.Errorf("[%d] File should not be installed: %s", i, f)
+			}
+		}
+	}
+}
+
+// testStripItemJSONFormat represents an item "sdk" whose archive is stripped by one component
+// except for version 2.0.0
+const testStripItemJSONFormat = `{
+  "meta": {
+    "url-format": "http://%s/archive/sdk-{{.Version}}.zip",
+    "strip-components": 1
+  },
+  "latest": {
+    "version": "2.0.0"
+  },
+  "versions": [
+    {
+      "version": "2.0.0",
+      "strip-components": 0
+    },
+    {
+      "version": "1.0.0"
+    }
+  ]
+}`
+
+// TestStripComponentsOverride checks that explicit 0 overrides "strip-components" in Item Manifest
+func TestStripComponentsOverride(t *testing.T) {
+	archive := newZipArchive(t, []testArchiveFile{{"go/bin/go", 0755}})
+	mux := newTestMux(map[string]string{"sdk": testStripItemJSONFormat})
+	mux.HandleFunc("/archive/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cases := []struct {
+		source string
+		strip  *int
+		want   string
+	}{
+		{source: "sdk@1.0.0", want: "sdk/bin/go"},
+		// Archive is installed as it is without stripping
+		{source: "sdk@1.0.0", strip: intRef(0), want: "sdk-1.0.0/go/bin/go"},
+		{source: "sdk@2.0.0", want: "sdk-2.0.0/go/bin/go"},
+		{source: "sdk@2.0.0", strip: intRef(1), want: "sdk/bin/go"},
+	}
+	for i, c := range cases {
+		tmpdir := t.TempDir()
+		log := &strings.Builder{}
+
+		err := Run(RunOption{
+			Mode:            ModeExtract,
+			Source:          c.source,
+			DestDir:         tmpdir,
+			Output:          log,
+			LogLevel:        lv.LNotice,
+			ServerURL:       ts.URL,
+			StripComponents: c.strip,
+		})
+		if err != nil {
+			t.Fatalf("[%d] Install failed. %v\nLog: %s", i, err, log)
+		}
+		if _, err = os.Stat(filepath.Join(tmpdir, c.want)); err != nil {
+			t.Errorf("[%d] Installed file not found: %s. %v", i, c.want, err)
+		}
+	}
+}
+
+func intRef(n int) *int {
+	return &n
+}
@@ -0,0 +1,266 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/binqry/binq"
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/schema/project"
+	"github.com/progrhyme/go-lv"
+)
+
+type SyncOption struct {
+	// Path to Toolfile
+	Toolfile string
+	// Path to Lockfile. Lockfile is not used when empty
+	Lockfile string
+	Output   io.Writer
+	LogLevel lv.Level
+	// Logger to use instead of the one made of Output and LogLevel
+	Logger lv.Granular
+	// Path to the registry file in which installation is recorded
+	RegistryPath string
+	// Path to configuration file which has settings for index servers. Not used when empty
+	ConfigPath string
+	// Directory to cache downloaded files. Cache is not used when empty
+	CacheDir string
+	// Directory to cache Index and Item JSON. Cache is not used when empty
+	IndexCacheDir string
+	// Install items only from caches without network access
+	Offline bool
+	// Options for HTTP requests such as timeouts and proxy
+	HTTPOptions http.Options
+	// Callback to receive download progress. Progress is not reported when nil
+	ProgressFunc ProgressFunc
+}
+
+// SyncResult represents what Sync has done. Each element is in form of "NAME[@VERSION] (DIR)"
+type SyncResult struct {
+	Installed []string
+	Removed   []string
+	Unchanged []string
+}
+
+// Sync makes installed tools match the ones declared in Toolfile.
+// It installs tools which are missing or whose versions differ; and uninstalls tools which are
+// installed in the directories managed by Toolfile but are no longer declared.
+func Sync(opt SyncOption) (result *SyncResult, err error) {
+	return SyncContext(context.Background(), opt)
+}
+
+// SyncContext is Sync with ctx. When ctx is done, installation in progress is aborted and Sync
+// stops without uninstalling anything
+func SyncContext(ctx context.Context, opt SyncOption) (result *SyncResult, err error) {
+	logger := newLogger(opt.Logger, opt.Output, opt.LogLevel)
+
+	tf, err := project.LoadToolfile(opt.Toolfile)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("Toolfile: %s", tf)
+
+	result = &SyncResult{}
+	declared := make(map[string]bool)
+	managedDirs := make(map[string]bool)
+	defaultDir, err := tf.GetDir(project.Tool{})
+	if err != nil {
+		return nil, err
+	}
+	managedDirs[defaultDir] = true
+	var failed []string
+	for _, tool := range tf.Tools {
+		dir, err := tf.GetDir(tool)
+		if err != nil {
+			return result, err
+		}
+		declared[tool.Name+"\n"+dir] = true
+		managedDirs[dir] = true
+		label := fmt.Sprintf("%s (%s)", tool.Source(), dir)
+
+		reg, err := registry.Load(opt.RegistryPath)
+		if err != nil {
+			return result, err
+		}
+		server, err := toolServer(tf, tool, opt.Lockfile)
+		if err != nil {
+			return result, err
+		}
+		prev := reg.Get(tool.Name, dir)
+		if isSatisfied(prev, tool, server) {
+			logger.Infof("Already installed: %s", label)
+			result.Unchanged = append(result.Unchanged, label)
+			continue
+		}
+
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return result, fmt.Errorf("Can't make directory: %s", dir)
+		}
+		logger.Noticef("Install %s", label)
+		_err := New(RunOption{
+			Source:        tool.Source(),
+			DestDir:       dir,
+			DestFile:      tool.File,
+			Include:       tool.Include,
+			Exclude:       tool.Exclude,
+			Logger:        logger,
+			ServerURL:     tf.GetServer(tool),
+			RegistryPath:  opt.RegistryPath,
+			LockfilePath:  opt.Lockfile,
+			ConfigPath:    opt.ConfigPath,
+			CacheDir:      opt.CacheDir,
+			IndexCacheDir: opt.IndexCacheDir,
+			Offline:       opt.Offline,
+			HTTPOptions:   opt.HTTPOptions,
+			ProgressFunc:  opt.ProgressFunc,
+		}).Run(ctx)
+		if _err != nil {
+			if err = ctx.Err(); err != nil {
+				return result, err
+			}
+			logger.Errorf("Failed to install %s. %v", label, _err)
+			failed = append(failed, tool.Source())
+			continue
+		}
+		if prev != nil {
+			if _err = removeStaleFiles(opt.RegistryPath, *prev, logger); _err != nil {
+				logger.Warnf("%v", _err)
+			}
+		}
+		result.Installed = append(result.Installed, label)
+	}
+
+	reg, err := registry.Load(opt.RegistryPath)
+	if err != nil {
+		return result, err
+	}
+	for _, entry := range reg.Entries {
+		if !managedDirs[entry.Dir] || declared[entry.Name+"\n"+entry.Dir] {
+			continue
+		}
+		label := fmt.Sprintf("%s (%s)", entry.Name, entry.Dir)
+		logger.Noticef("Uninstall %s", label)
+		_, _err := Uninstall(UninstallOption{
+			Name:         entry.Name,
+			DestDir:      entry.Dir,
+			Logger:       logger,
+			RegistryPath: opt.RegistryPath,
+		})
+		if _err != nil {
+			logger.Errorf("Failed to uninstall %s. %v", label, _err)
+			failed = append(failed, entry.Name)
+			continue
+		}
+		result.Removed = append(result.Removed, label)
+	}
+
+	if opt.Lockfile != "" {
+		if err = pruneLockfile(opt.Lockfile, tf, logger); err != nil {
+			return result, err
+		}
+	}
+
+	if len(failed) > 0 {
+		return result, fmt.Errorf("Failed to sync: %s", strings.Join(failed, ", "))
+	}
+	return result, nil
+}
+
+// pruneLockfile removes items which are not declared in Toolfile from Lockfile
+func pruneLockfile(path string, tf *project.Toolfile, logger lv.Granular) (err error) {
+	lf, err := project.LoadLockfile(path)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool)
+	for _, tool := range tf.Tools {
+		names[tool.Name] = true
+	}
+	// Collect names at first because Remove shifts items in the slice
+	var pruned []string
+	for _, locked := range lf.Items {
+		if !names[locked.Name] {
+			pruned = append(pruned, locked.Name)
+		}
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+	for _, name := range pruned {
+		lf.Remove(name)
+		logger.Infof("Unlocked %s", name)
+	}
+	return lf.Save()
+}
+
+// toolServer returns URL of the index server from which tool is installed. Like Runner, the
+// server pinned in Lockfile takes precedence; and environment variable or the default server is
+// used when Toolfile does not declare it
+func toolServer(tf *project.Toolfile, tool project.Tool, lockPath string) (server string, err error) {
+	if lockPath != "" {
+		lf, err := project.LoadLockfile(lockPath)
+		if err != nil {
+			return "", err
+		}
+		locked := lf.Find(tool.Name)
+		if locked != nil && locked.Server != "" && (tool.Version == "" || tool.Version == locked.Version) {
+			return locked.Server, nil
+		}
+	}
+	if server = tf.GetServer(tool); server == "" {
+		server = os.Getenv(binq.EnvKeyServer)
+	}
+	if server == "" {
+		server = binq.DefaultBinqServer
+	}
+	uri, _err := url.Parse(server)
+	if _err != nil {
+		return "", erron.Errorwf(_err, "Failed to parse server URL: %s", server)
+	}
+	return uri.String(), nil
+}
+
+// isSatisfied checks whether installed entry meets the declaration of tool which is installed
+// from server. Every parameter of installation is compared as well as the version
+func isSatisfied(entry *registry.Entry, tool project.Tool, server string) bool {
+	if entry == nil {
+		return false
+	}
+	if tool.Version != "" && entry.Version != tool.Version {
+		return false
+	}
+	if entry.Server != server || entry.DestFile != tool.File {
+		return false
+	}
+	if !sameStrings(entry.Include, tool.Include) || !sameStrings(entry.Exclude, tool.Exclude) {
+		return false
+	}
+	// Toolfile has no parameters for them; nor for versioned layout
+	if entry.StripComponents != nil || entry.Subdir != "" || entry.PkgDir != "" {
+		return false
+	}
+	for _, f := range entry.Files {
+		if _, err := os.Stat(f); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
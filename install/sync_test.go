@@ -0,0 +1,105 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/binqry/binq/schema/project"
+	"github.com/progrhyme/go-lv"
+)
+
+func TestSync(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(map[string]string{"foo": testItemJSONFormat}))
+	defer ts.Close()
+	mirror := httptest.NewServer(newTestMux(map[string]string{"foo": testItemJSONFormat}))
+	defer mirror.Close()
+	tmpdir := t.TempDir()
+
+	regPath := filepath.Join(tmpdir, "installed.json")
+	toolfile := filepath.Join(tmpdir, "binq.json")
+	dest := filepath.Join(tmpdir, "bin", "foo")
+	log := &strings.Builder{}
+
+	testCases := []struct {
+		items                         string
+		installed, removed, unchanged int
+		exists                        bool
+	}{
+		{items: `["foo@0.1.0"]`, installed: 1, exists: true},
+		{items: `["foo@0.1.0"]`, unchanged: 1, exists: true},
+		{items: `[{"name": "foo", "version": "0.2.0"}]`, installed: 1, exists: true},
+		{items: `[{"name": "foo", "version": "0.2.0"}]`, unchanged: 1, exists: true},
+		// Changes of parameters other than version also require installation
+		{items: `[{"name": "foo", "version": "0.2.0", "exclude": ["*.txt"]}]`, installed: 1, exists: true},
+		{items: `[{"name": "foo", "version": "0.2.0", "exclude": ["*.txt"]}]`, unchanged: 1, exists: true},
+		{items: fmt.Sprintf(`[{"name": "foo", "version": "0.2.0", "exclude": ["*.txt"], "server": "%s"}]`,
+			mirror.URL), installed: 1, exists: true},
+		{items: `[]`, removed: 1, exists: false},
+	}
+	for i, tc := range testCases {
+		content := fmt.Sprintf(`{"server": "%s", "items": %s}`, ts.URL, tc.items)
+		if err := ioutil.WriteFile(toolfile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write Toolfile. %v", err)
+		}
+		result, err := Sync(SyncOption{
+			Toolfile: toolfile, Output: log, LogLevel: lv.LNotice, RegistryPath: regPath,
+		})
+		if err != nil {
+			t.Fatalf("[%d] Sync failed. %v\nLog: %s", i, err, log)
+		}
+		if len(result.Installed) != tc.installed || len(result.Removed) != tc.removed ||
+			len(result.Unchanged) != tc.unchanged {
+			t.Errorf("[%d] Sync result mismatch. Got: %+v", i, result)
+		}
+		if _, err = os.Stat(dest); (err == nil) != tc.exists {
+			t.Errorf("[%d] Existence of %s mismatch. Want: %v", i, dest, tc.exists)
+		}
+	}
+}
+
+func TestSyncPruneLockfile(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(map[string]string{"foo": testItemJSONFormat}))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	toolfile := filepath.Join(tmpdir, "binq.json")
+	lockPath := filepath.Join(tmpdir, "binq.lock")
+	content := fmt.Sprintf(`{"server": "%s", "dir": "%s", "items": ["foo@0.1.0"]}`, ts.URL, tmpdir)
+	if err := ioutil.WriteFile(toolfile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write Toolfile. %v", err)
+	}
+	lf, err := project.LoadLockfile(lockPath)
+	if err != nil {
+		t.Fatalf("Failed to load Lockfile. %v", err)
+	}
+	// Adjacent items not declared in Toolfile
+	for _, name := range []string{"a", "b", "c"} {
+		lf.Put(project.LockedItem{Name: name, Version: "1.0.0"})
+	}
+	if err = lf.Save(); err != nil {
+		t.Fatalf("Failed to save Lockfile. %v", err)
+	}
+
+	log := &strings.Builder{}
+	_, err = Sync(SyncOption{
+		Toolfile:     toolfile,
+		Lockfile:     lockPath,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		RegistryPath: filepath.Join(tmpdir, "installed.json"),
+	})
+	if err != nil {
+		t.Fatalf("Sync failed. %v\nLog: %s", err, log)
+	}
+	if lf, err = project.LoadLockfile(lockPath); err != nil {
+		t.Fatalf("Failed to load Lockfile. %v", err)
+	}
+	if len(lf.Items) != 1 || lf.Items[0].Name != "foo" {
+		t.Errorf("Locked items mismatch. Want: [foo], Got: %+v", lf.Items)
+	}
+}
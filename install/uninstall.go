@@ -0,0 +1,67 @@
+package install
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/progrhyme/go-lv"
+)
+
+var ErrNotInstalled = errors.New("Item is not installed")
+
+type UninstallOption struct {
+	Name     string
+	DestDir  string
+	Output   io.Writer
+	LogLevel lv.Level
+	// Logger to use instead of the one made of Output and LogLevel
+	Logger lv.Granular
+	// Path to the registry file in which installation is recorded
+	RegistryPath string
+}
+
+// Uninstall removes files of the item recorded in the registry; and deletes its entries from
+// the registry. When opt.DestDir is empty, installations in all directories are removed.
+// Versions installed in versioned layout are removed when no installation refers to them.
+func Uninstall(opt UninstallOption) (removed []registry.Entry, err error) {
+	logger := newLogger(opt.Logger, opt.Output, opt.LogLevel)
+
+	reg, err := registry.Load(opt.RegistryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var dir string
+	if opt.DestDir != "" {
+		if dir, err = filepath.Abs(opt.DestDir); err != nil {
+			return nil, erron.Errorwf(err, "Failed to get absolute path: %s", opt.DestDir)
+		}
+	}
+	removed = reg.Remove(opt.Name, dir)
+	if len(removed) == 0 {
+		return nil, ErrNotInstalled
+	}
+
+	for _, entry := range removed {
+		for _, file := range entry.Files {
+			if _, _err := os.Lstat(file); os.IsNotExist(_err) {
+				logger.Warnf("Already removed: %s", file)
+				continue
+			}
+			if _err := os.RemoveAll(file); _err != nil {
+				return nil, erron.Errorwf(_err, "Failed to remove: %s", file)
+			}
+			logger.Printf("Removed %s", file)
+		}
+		removePkgs(reg, entry, logger)
+	}
+
+	if err = reg.Save(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
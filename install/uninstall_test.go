@@ -0,0 +1,74 @@
+package install
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/binqry/binq/install/registry"
+	"github.com/progrhyme/go-lv"
+)
+
+func TestUninstall(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(nil))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	regPath := filepath.Join(tmpdir, "registry", "installed.json")
+	log := &strings.Builder{}
+	err := Run(RunOption{
+		Source:       ts.URL + "/download/foo",
+		DestDir:      tmpdir,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		RegistryPath: regPath,
+	})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+
+	dest := filepath.Join(tmpdir, "foo")
+	fi, err := os.Stat(dest)
+	if err != nil {
+		t.Fatalf("Installed file not found: %s. %v", dest, err)
+	}
+	if fi.Mode()&0111 == 0 {
+		t.Errorf("Installed file is not executable: %s", dest)
+	}
+
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	entries := reg.Find("foo")
+	if len(entries) != 1 {
+		t.Fatalf("Registry entries mismatch. Want: 1, Got: %d. Registry: %s", len(entries), reg)
+	}
+	if got := entries[0].Files; len(got) != 1 || got[0] != dest {
+		t.Errorf("Recorded files mismatch. Want: [%s], Got: %v", dest, got)
+	}
+
+	removed, err := Uninstall(UninstallOption{
+		Name:         "foo",
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		RegistryPath: regPath,
+	})
+	if err != nil {
+		t.Fatalf("Uninstall failed. %v", err)
+	}
+	if len(removed) != 1 {
+		t.Errorf("Removed entries mismatch. Want: 1, Got: %d", len(removed))
+	}
+	if _, err = os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("File remains after uninstall: %s", dest)
+	}
+
+	if _, err = Uninstall(UninstallOption{
+		Name: "foo", Output: log, LogLevel: lv.LNotice, RegistryPath: regPath,
+	}); err != ErrNotInstalled {
+		t.Errorf("Uninstall error mismatch. Want: %v, Got: %v", ErrNotInstalled, err)
+	}
+}
@@ -0,0 +1,143 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/install/registry"
+	"github.com/progrhyme/go-lv"
+)
+
+type UpgradeOption struct {
+	// Names of items to upgrade. All installed items are upgraded when empty
+	Names    []string
+	Output   io.Writer
+	LogLevel lv.Level
+	// Logger to use instead of the one made of Output and LogLevel
+	Logger lv.Granular
+	// Path to the registry file in which installation is recorded
+	RegistryPath string
+	// Path to configuration file which has settings for index servers. Not used when empty
+	ConfigPath string
+	// Directory to cache downloaded files. Cache is not used when empty
+	CacheDir string
+	// Directory to cache Index and Item JSON. Cache is not used when empty
+	IndexCacheDir string
+	// Options for HTTP requests such as timeouts and proxy
+	HTTPOptions http.Options
+	// Callback to receive download progress. Progress is not reported when nil
+	ProgressFunc ProgressFunc
+}
+
+// Upgrade reinstalls the latest versions of outdated items into the same directories where they
+// are installed
+func Upgrade(opt UpgradeOption) (upgraded []Outdated, err error) {
+	return UpgradeContext(context.Background(), opt)
+}
+
+// UpgradeContext is Upgrade with ctx. When ctx is done, installation in progress is aborted and
+// the rest of items are not upgraded
+func UpgradeContext(ctx context.Context, opt UpgradeOption) (upgraded []Outdated, err error) {
+	logger := newLogger(opt.Logger, opt.Output, opt.LogLevel)
+
+	outdated, err := FindOutdatedContext(ctx, OutdatedOption{
+		Names:        opt.Names,
+		Logger:       logger,
+		RegistryPath: opt.RegistryPath,
+		ConfigPath:   opt.ConfigPath,
+		HTTPOptions:  opt.HTTPOptions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []string
+	for _, o := range outdated {
+		logger.Noticef("Upgrade %s", o)
+		_err := New(RunOption{
+			Mode:            Mode(o.entry.Mode),
+			Source:          o.Name,
+			DestDir:         o.Dir,
+			DestFile:        o.entry.DestFile,
+			Logger:          logger,
+			ServerURL:       o.Server,
+			ItemPath:        o.entry.Path,
+			NewerThan:       o.Current,
+			RegistryPath:    opt.RegistryPath,
+			ConfigPath:      opt.ConfigPath,
+			CacheDir:        opt.CacheDir,
+			IndexCacheDir:   opt.IndexCacheDir,
+			HTTPOptions:     opt.HTTPOptions,
+			ProgressFunc:    opt.ProgressFunc,
+			PkgsDir:         pkgsDirOf(o.entry),
+			Include:         o.entry.Include,
+			Exclude:         o.entry.Exclude,
+			StripComponents: o.entry.StripComponents,
+			Subdir:          o.entry.Subdir,
+		}).Run(ctx)
+		if _err != nil {
+			if err = ctx.Err(); err != nil {
+				return upgraded, err
+			}
+			logger.Errorf("Failed to upgrade %s. %v", o.Name, _err)
+			failed = append(failed, o.Name)
+			continue
+		}
+		if _err = removeStaleFiles(opt.RegistryPath, o.entry, logger); _err != nil {
+			logger.Warnf("%v", _err)
+		}
+		upgraded = append(upgraded, o)
+	}
+
+	if len(failed) > 0 {
+		return upgraded, fmt.Errorf("Failed to upgrade: %s", strings.Join(failed, ", "))
+	}
+	return upgraded, nil
+}
+
+// removeStaleFiles removes files which were installed by old entry but not by the new one
+func removeStaleFiles(regPath string, old registry.Entry, logger lv.Granular) (err error) {
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return err
+	}
+	current := reg.Get(old.Name, old.Dir)
+	if current == nil {
+		// Unexpected
+		return fmt.Errorf("Installation is not recorded. Name: %s, Dir: %s", old.Name, old.Dir)
+	}
+
+	kept := make(map[string]bool)
+	for _, f := range current.Files {
+		kept[f] = true
+	}
+	for _, f := range old.Files {
+		if kept[f] {
+			continue
+		}
+		if _err := os.RemoveAll(f); _err != nil {
+			logger.Warnf("Failed to remove old file: %s. %v", f, _err)
+			continue
+		}
+		logger.Infof("Removed old file %s", f)
+	}
+	return nil
+}
+
+// pkgsDirOf returns the directory for versioned layout in which entry is installed.
+// It returns empty string when entry is not installed in versioned layout
+func pkgsDirOf(entry registry.Entry) (dir string) {
+	if entry.PkgDir == "" {
+		return ""
+	}
+	if entry.PkgsDir != "" {
+		return entry.PkgsDir
+	}
+	// Entry recorded without PkgsDir. PkgDir is PKGS_DIR/NAME/VERSION where NAME has no "/"
+	return filepath.Dir(filepath.Dir(entry.PkgDir))
+}
@@ -0,0 +1,124 @@
+package install
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/binqry/binq/install/registry"
+	"github.com/progrhyme/go-lv"
+)
+
+func TestOutdatedAndUpgrade(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(map[string]string{"foo": testItemJSONFormat}))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	regPath := filepath.Join(tmpdir, "installed.json")
+	log := &strings.Builder{}
+	err := Run(RunOption{
+		Source:       "foo@0.1.0",
+		DestDir:      tmpdir,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		ServerURL:    ts.URL,
+		RegistryPath: regPath,
+	})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+
+	outdated, err := FindOutdated(OutdatedOption{Output: log, LogLevel: lv.LNotice, RegistryPath: regPath})
+	if err != nil {
+		t.Fatalf("FindOutdated failed. %v\nLog: %s", err, log)
+	}
+	if len(outdated) != 1 || outdated[0].Current != "0.1.0" || outdated[0].Latest != "0.2.0" {
+		t.Fatalf("Outdated items mismatch. Got: %v", outdated)
+	}
+
+	upgraded, err := Upgrade(UpgradeOption{Output: log, LogLevel: lv.LNotice, RegistryPath: regPath})
+	if err != nil {
+		t.Fatalf("Upgrade failed. %v\nLog: %s", err, log)
+	}
+	if len(upgraded) != 1 {
+		t.Errorf("Upgraded items mismatch. Got: %v", upgraded)
+	}
+
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	entry := reg.Get("foo", tmpdir)
+	if entry == nil || entry.Version != "0.2.0" {
+		t.Errorf("Recorded version mismatch. Want: 0.2.0, Got: %+v", entry)
+	}
+
+	outdated, err = FindOutdated(OutdatedOption{Output: log, LogLevel: lv.LNotice, RegistryPath: regPath})
+	if err != nil || len(outdated) != 0 {
+		t.Errorf("Outdated items remain after upgrade: %v, Error: %v", outdated, err)
+	}
+}
+
+// TestUpgradeByPath checks that the item is looked up by its path on the index server recorded on
+// installation; not by its name in the index which can be changed
+func TestUpgradeByPath(t *testing.T) {
+	indexed := true
+	mux := newTestMux(nil)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" || !indexed {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"items": [{"name": "foo", "path": "tools/foo"}]}`)
+	})
+	mux.HandleFunc("/tools/foo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, testItemJSONFormat, r.Host)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	regPath := filepath.Join(tmpdir, "installed.json")
+	log := &strings.Builder{}
+	err := Run(RunOption{
+		Source:       "foo@0.1.0",
+		DestDir:      tmpdir,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		ServerURL:    ts.URL,
+		RegistryPath: regPath,
+	})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	if entry := reg.Get("foo", tmpdir); entry == nil || entry.Path != "tools/foo" {
+		t.Fatalf("Recorded path mismatch. Want: tools/foo, Got: %+v", entry)
+	}
+
+	// Name can't be resolved any more
+	indexed = false
+	outdated, err := FindOutdated(OutdatedOption{Output: log, LogLevel: lv.LNotice, RegistryPath: regPath})
+	if err != nil {
+		t.Fatalf("FindOutdated failed. %v\nLog: %s", err, log)
+	}
+	if len(outdated) != 1 || outdated[0].Latest != "0.2.0" {
+		t.Fatalf("Outdated items mismatch. Got: %v\nLog: %s", outdated, log)
+	}
+	upgraded, err := Upgrade(UpgradeOption{Output: log, LogLevel: lv.LNotice, RegistryPath: regPath})
+	if err != nil || len(upgraded) != 1 {
+		t.Fatalf("Upgrade failed. Upgraded: %v, Error: %v\nLog: %s", upgraded, err, log)
+	}
+	if reg, err = registry.Load(regPath); err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	if entry := reg.Get("foo", tmpdir); entry == nil || entry.Version != "0.2.0" || entry.Path != "tools/foo" {
+		t.Errorf("Recorded entry mismatch. Want: 0.2.0 on tools/foo, Got: %+v", entry)
+	}
+}
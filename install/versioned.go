@@ -0,0 +1,292 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/xdg"
+	"github.com/progrhyme/go-lv"
+)
+
+var (
+	ErrNotVersioned      = errors.New("Item is not installed in versioned layout")
+	ErrNoPreviousVersion = errors.New("No previous version to roll back to")
+)
+
+// DefaultPkgsDir returns the directory for versioned layout under $XDG_DATA_HOME
+func DefaultPkgsDir() (path string) {
+	return filepath.Join(xdg.DataDir(), "pkgs")
+}
+
+// locateVersioned installs files into PkgsDir/NAME/VERSION and links them from DestDir
+func (r *Runner) locateVersioned(ctx context.Context) (err error) {
+	if r.sourceItem == nil {
+		return fmt.Errorf("Versioned layout requires an item on index server. Source: %s", r.Source)
+	}
+	ver := r.sourceItem.Version
+	if !isValidPkgVersion(ver) {
+		return fmt.Errorf("Invalid version for versioned layout: %s", ver)
+	}
+	pkgDir, _err := filepath.Abs(filepath.Join(r.PkgsDir, r.itemName, ver))
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to get absolute path: %s", r.PkgsDir)
+	}
+	if _err = os.MkdirAll(pkgDir, 0755); _err != nil {
+		return erron.Errorwf(_err, "Failed to make directory: %s", pkgDir)
+	}
+
+	s, err := newStage(pkgDir)
+	if err != nil {
+		return err
+	}
+	_, extra, err := r.stageFiles(ctx, s, pkgDir)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		s.abort()
+		if extra != nil {
+			extra.abort()
+		}
+		return err
+	}
+	if err = commitStages(append([]*stage{s}, extra.list()...)...); err != nil {
+		return err
+	}
+
+	oldLinks, err := r.activeLinks()
+	if err != nil {
+		return err
+	}
+	links, err := linkVersion(r.DestDir, pkgDir, oldLinks)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		r.Logger.Printf("Installed %s -> %s", l, filepath.Join(pkgDir, filepath.Base(l)))
+	}
+	for _, f := range extra.dests() {
+		r.Logger.Printf("Installed %s", f)
+	}
+	links = append(links, extra.dests()...)
+	r.installed = links
+	r.pkgDir = pkgDir
+	return nil
+}
+
+// activeLinks returns links of the version currently active in DestDir according to the registry
+func (r *Runner) activeLinks() (links []string, err error) {
+	if r.RegistryPath == "" {
+		return nil, nil
+	}
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	reg, err := registry.Load(r.RegistryPath)
+	if err != nil {
+		return nil, err
+	}
+	dir, _err := filepath.Abs(r.DestDir)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to get absolute path: %s", r.DestDir)
+	}
+	if entry := reg.Get(r.itemName, dir); entry != nil && entry.PkgDir != "" {
+		return entry.Files, nil
+	}
+	return nil, nil
+}
+
+// linkVersion places symbolic links to files in pkgDir into destDir all together; and removes
+// oldLinks which are not replaced. Only symbolic links are removed.
+// Returned links are absolute paths even if destDir is relative or empty
+func linkVersion(destDir, pkgDir string, oldLinks []string) (links []string, err error) {
+	dir, _err := filepath.Abs(destDir)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to get absolute path: %s", destDir)
+	}
+	files, _err := ioutil.ReadDir(pkgDir)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to read directory: %s", pkgDir)
+	}
+	s, err := newStage(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), stagePrefix) {
+			continue
+		}
+		dest := filepath.Join(dir, f.Name())
+		if err = s.addSymlink(filepath.Join(pkgDir, f.Name()), dest); err != nil {
+			s.abort()
+			return nil, err
+		}
+		links = append(links, dest)
+	}
+	if err = s.commit(); err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool)
+	for _, l := range links {
+		current[l] = true
+	}
+	for _, l := range oldLinks {
+		if current[l] {
+			continue
+		}
+		if fi, _err := os.Lstat(l); _err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			os.Remove(l)
+		}
+	}
+	return links, nil
+}
+
+type UseOption struct {
+	Name string
+	// Version to activate. Rollback ignores it
+	Version string
+	// Directory where the item is installed. It can be omitted when the item is installed in
+	// only one directory
+	DestDir  string
+	Output   io.Writer
+	LogLevel lv.Level
+	// Logger to use instead of the one made of Output and LogLevel
+	Logger lv.Granular
+	// Path to the registry file in which installation is recorded
+	RegistryPath string
+}
+
+// Use switches symbolic links of the item installed in versioned layout to opt.Version which is
+// already installed; and returns the updated entry
+func Use(opt UseOption) (entry *registry.Entry, err error) {
+	return switchVersion(opt, func(e *registry.Entry) string { return opt.Version })
+}
+
+// Rollback switches the item installed in versioned layout back to the version which was active
+// before the current one
+func Rollback(opt UseOption) (entry *registry.Entry, err error) {
+	return switchVersion(opt, func(e *registry.Entry) string { return e.Previous })
+}
+
+func switchVersion(opt UseOption, version func(*registry.Entry) string) (entry *registry.Entry, err error) {
+	logger := newLogger(opt.Logger, opt.Output, opt.LogLevel)
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	reg, err := registry.Load(opt.RegistryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.DestDir != "" {
+		dir, _err := filepath.Abs(opt.DestDir)
+		if _err != nil {
+			return nil, erron.Errorwf(_err, "Failed to get absolute path: %s", opt.DestDir)
+		}
+		if entry = reg.Get(opt.Name, dir); entry == nil {
+			return nil, ErrNotInstalled
+		}
+	} else {
+		var candidates []registry.Entry
+		for _, e := range reg.Find(opt.Name) {
+			if e.PkgDir != "" {
+				candidates = append(candidates, e)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			if len(reg.Find(opt.Name)) == 0 {
+				return nil, ErrNotInstalled
+			}
+			return nil, ErrNotVersioned
+		case 1:
+			entry = &candidates[0]
+		default:
+			dirs := make([]string, 0, len(candidates))
+			for _, e := range candidates {
+				dirs = append(dirs, e.Dir)
+			}
+			return nil, fmt.Errorf("%s is installed in multiple directories. Specify one of: %s",
+				opt.Name, strings.Join(dirs, ", "))
+		}
+	}
+	if entry.PkgDir == "" {
+		return nil, ErrNotVersioned
+	}
+
+	ver := version(entry)
+	if ver == "" {
+		return nil, ErrNoPreviousVersion
+	}
+	if ver == entry.Version {
+		logger.Infof("%s@%s is already active in %s", entry.Name, ver, entry.Dir)
+		return entry, nil
+	}
+	if !isValidPkgVersion(ver) {
+		return nil, fmt.Errorf("Invalid version: %s", ver)
+	}
+	pkgDir := filepath.Join(filepath.Dir(entry.PkgDir), ver)
+	if _, _err := os.Stat(pkgDir); _err != nil {
+		return nil, erron.Errorwf(_err, "%s@%s is not installed", entry.Name, ver)
+	}
+
+	links, err := linkVersion(entry.Dir, pkgDir, entry.Files)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		logger.Infof("Linked %s -> %s", l, filepath.Join(pkgDir, filepath.Base(l)))
+	}
+	for _, f := range entry.Files {
+		// Keep files in share directory which are not versioned
+		if filepath.Dir(f) != entry.Dir {
+			links = append(links, f)
+		}
+	}
+
+	// URL and checksum are unknown for the version installed before they are recorded per version
+	pkg := entry.Pkgs[ver]
+	entry.Previous, entry.Version = entry.Version, ver
+	entry.Source = fmt.Sprintf("%s@%s", entry.Name, ver)
+	entry.URL = pkg.URL
+	entry.Checksum = pkg.Checksum
+	entry.PkgDir = pkgDir
+	entry.Files = links
+	reg.Add(*entry)
+	if err = reg.Save(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// isValidPkgVersion checks that ver can be a directory name in PkgsDir/NAME. Versions come from
+// index server; so they must not point outside of it
+func isValidPkgVersion(ver string) bool {
+	return ver != "" && ver != "." && ver != ".." && ver == filepath.Base(ver)
+}
+
+// removePkgs removes versions of the item in versioned layout when no entry in reg refers to them
+func removePkgs(reg *registry.Registry, removed registry.Entry, logger lv.Granular) {
+	if removed.PkgDir == "" {
+		return
+	}
+	pkgs := filepath.Dir(removed.PkgDir)
+	for _, e := range reg.Find(removed.Name) {
+		if e.PkgDir != "" && filepath.Dir(e.PkgDir) == pkgs {
+			return
+		}
+	}
+	if _err := os.RemoveAll(pkgs); _err != nil {
+		logger.Warnf("Failed to remove: %s. %v", pkgs, _err)
+		return
+	}
+	logger.Printf("Removed %s", pkgs)
+}
@@ -0,0 +1,219 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/binqry/binq/install/registry"
+	"github.com/progrhyme/go-lv"
+)
+
+func TestVersioned(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(map[string]string{"foo": testItemJSONFormat}))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	regPath := filepath.Join(tmpdir, "installed.json")
+	binDir := filepath.Join(tmpdir, "bin")
+	pkgsDir := filepath.Join(tmpdir, "pkgs")
+	if err := os.Mkdir(binDir, 0755); err != nil {
+		t.Fatalf("Failed to make directory. %v", err)
+	}
+	log := &strings.Builder{}
+	err := Run(RunOption{
+		Source:       "foo@0.1.0",
+		DestDir:      binDir,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		ServerURL:    ts.URL,
+		RegistryPath: regPath,
+		PkgsDir:      pkgsDir,
+	})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+
+	link := filepath.Join(binDir, "foo")
+	assertLink := func(version string) {
+		t.Helper()
+		want := filepath.Join(pkgsDir, "foo", version, "foo")
+		if got, err := os.Readlink(link); err != nil || got != want {
+			t.Errorf("Link target mismatch. Want: %s, Got: %s, Error: %v", want, got, err)
+		}
+		if b, err := ioutil.ReadFile(link); err != nil || string(b) != testContent {
+			t.Errorf("Linked content mismatch. Got: %q, Error: %v", b, err)
+		}
+	}
+	assertLink("0.1.0")
+
+	if _, err = Upgrade(UpgradeOption{Output: log, LogLevel: lv.LNotice, RegistryPath: regPath}); err != nil {
+		t.Fatalf("Upgrade failed. %v\nLog: %s", err, log)
+	}
+	assertLink("0.2.0")
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	if entry := reg.Get("foo", binDir); entry == nil || entry.Version != "0.2.0" || entry.Previous != "0.1.0" {
+		t.Errorf("Recorded entry mismatch. Got: %+v", entry)
+	}
+
+	opt := UseOption{Name: "foo", Output: log, LogLevel: lv.LNotice, RegistryPath: regPath}
+	entry, err := Rollback(opt)
+	if err != nil || entry.Version != "0.1.0" || entry.Previous != "0.2.0" {
+		t.Fatalf("Rollback failed. Entry: %+v, Error: %v", entry, err)
+	}
+	assertLink("0.1.0")
+	if entry.URL != ts.URL+"/download/foo-0.1.0" || entry.Checksum == nil {
+		t.Errorf("Source of the version is lost on rollback. Entry: %+v", entry)
+	}
+
+	opt.Version, opt.DestDir = "0.2.0", binDir
+	if entry, err = Use(opt); err != nil || entry.Version != "0.2.0" {
+		t.Fatalf("Use failed. Entry: %+v, Error: %v", entry, err)
+	}
+	assertLink("0.2.0")
+	if entry.URL != ts.URL+"/download/foo-0.2.0" || entry.Checksum == nil {
+		t.Errorf("Source of the version is lost on switch. Entry: %+v", entry)
+	}
+
+	opt.Version = "9.9.9"
+	if _, err = Use(opt); err == nil {
+		t.Errorf("Use of version not installed should fail")
+	}
+	assertLink("0.2.0")
+
+	if _, err = Uninstall(UninstallOption{
+		Name: "foo", Output: log, LogLevel: lv.LNotice, RegistryPath: regPath,
+	}); err != nil {
+		t.Fatalf("Uninstall failed. %v", err)
+	}
+	for _, path := range []string{link, filepath.Join(pkgsDir, "foo")} {
+		if _, err = os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("File remains after uninstall: %s", path)
+		}
+	}
+}
+
+func TestVersionedInCurrentDir(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(map[string]string{"foo": testItemJSONFormat}))
+	defer ts.Close()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regPath := filepath.Join(cwd, "installed.json")
+	log := &strings.Builder{}
+	err = Run(RunOption{
+		Source:       "foo@0.1.0",
+		DestDir:      "",
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		ServerURL:    ts.URL,
+		RegistryPath: regPath,
+		PkgsDir:      "pkgs",
+	})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+	link := filepath.Join(cwd, "foo")
+	if _, err = os.Readlink(link); err != nil {
+		t.Errorf("Link not found in current directory. %v", err)
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	if entry := reg.Get("foo", cwd); entry == nil || len(entry.Files) != 1 || entry.Files[0] != link {
+		t.Errorf("Recorded files mismatch. Want: [%s], Got: %+v", link, entry)
+	}
+}
+
+// TestVersionedNestedName checks that upgrade keeps the directory for versioned layout of the item
+// whose name is a path on the index server
+func TestVersionedNestedName(t *testing.T) {
+	ts := httptest.NewServer(newTestMux(map[string]string{"tools/foo": testItemJSONFormat}))
+	defer ts.Close()
+	tmpdir := t.TempDir()
+
+	regPath := filepath.Join(tmpdir, "installed.json")
+	pkgsDir := filepath.Join(tmpdir, "pkgs")
+	log := &strings.Builder{}
+	err := Run(RunOption{
+		Source:       "tools/foo@0.1.0",
+		DestDir:      tmpdir,
+		Output:       log,
+		LogLevel:     lv.LNotice,
+		ServerURL:    ts.URL,
+		RegistryPath: regPath,
+		PkgsDir:      pkgsDir,
+	})
+	if err != nil {
+		t.Fatalf("Install failed. %v\nLog: %s", err, log)
+	}
+	if _, err = Upgrade(UpgradeOption{Output: log, LogLevel: lv.LNotice, RegistryPath: regPath}); err != nil {
+		t.Fatalf("Upgrade failed. %v\nLog: %s", err, log)
+	}
+
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry. %v", err)
+	}
+	want := filepath.Join(pkgsDir, "tools", "foo", "0.2.0")
+	if entry := reg.Get("tools/foo", tmpdir); entry == nil || entry.PkgDir != want || entry.PkgsDir != pkgsDir {
+		t.Errorf("Recorded entry mismatch. Want: %s in %s, Got: %+v", want, pkgsDir, entry)
+	}
+}
+
+// TestVersionedInvalidVersion checks that versions from index server can't make the package
+// directory outside of PkgsDir
+func TestVersionedInvalidVersion(t *testing.T) {
+	const format = `{
+  "meta": {
+    "url-format": "http://%%s/download/foo"
+  },
+  "latest": {
+    "version": "%s"
+  },
+  "versions": [
+    {
+      "version": "%s"
+    }
+  ]
+}`
+	for _, ver := range []string{"..", "../../..", "0.1.0/../../escaped"} {
+		ts := httptest.NewServer(newTestMux(map[string]string{"foo": fmt.Sprintf(format, ver, ver)}))
+		tmpdir := t.TempDir()
+		pkgsDir := filepath.Join(tmpdir, "a", "b", "pkgs")
+		log := &strings.Builder{}
+		err := Run(RunOption{
+			Source:    "foo",
+			DestDir:   tmpdir,
+			Output:    log,
+			LogLevel:  lv.LNotice,
+			ServerURL: ts.URL,
+			PkgsDir:   pkgsDir,
+		})
+		ts.Close()
+		if err == nil {
+			t.Errorf("Install of version %q should fail", ver)
+		}
+		if _, err = os.Stat(filepath.Join(tmpdir, "a")); !os.IsNotExist(err) {
+			t.Errorf("Directory is made for version %q", ver)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.json")
+	for _, content := range []string{"first\n", "second\n"} {
+		if err := WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile failed. %v", err)
+		}
+		if b, err := ioutil.ReadFile(path); err != nil || string(b) != content {
+			t.Errorf("Content mismatch. Want: %q, Got: %q, Error: %v", content, b, err)
+		}
+	}
+	fi, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fi) != 1 || fi[0].Mode().Perm() != 0600 {
+		t.Errorf("Temporary file remains or permission mismatch. Files: %v", fi)
+	}
+
+	if err = WriteFile(filepath.Join(dir, "no-such-dir", "file.json"), nil, 0644); err == nil {
+		t.Errorf("Expected error for missing directory but got nil")
+	}
+}
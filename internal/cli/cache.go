@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/install/cache"
+	"github.com/spf13/pflag"
+)
+
+type cacheCmd struct {
+	*commonCmd
+	option *cacheOpts
+}
+
+type cacheOpts struct {
+	outfmt, maxAge, maxSize *string
+	*commonOpts
+}
+
+func newCacheCmd(common *commonCmd) (self *cacheCmd) {
+	self = &cacheCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &cacheOpts{
+		outfmt:     fs.StringP("output", "o", "", "# [list] Output format (text,json)"),
+		maxAge:     fs.String("max-age", "30d", "# [prune] Remove files not used within the period"),
+		maxSize:    fs.String("max-size", "", "# [prune] Remove least recently used files to fit in the size"),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *cacheCmd) usage() {
+	const help = `Summary:
+  List or remove files downloaded and cached by <<.prog>>.
+
+Usage:
+  <<.prog>> <<.name>> list [-o|--output FORMAT] [GENERAL_OPTIONS]
+  <<.prog>> <<.name>> prune [--max-age AGE] [--max-size SIZE] [GENERAL_OPTIONS]
+  <<.prog>> <<.name>> clean [GENERAL_OPTIONS]
+
+Examples:
+  # Remove files not used in 2 weeks
+  <<.prog>> <<.name>> prune --max-age 14d
+
+  # Keep total size within 500MiB and remove files not used in 30 days
+  <<.prog>> <<.name>> prune --max-size 500M
+
+  # Remove all cached files
+  <<.prog>> <<.name>> clean
+
+Cached files are stored in <<.cache>>.
+"clean" also removes Index and Item JSON cached in <<.indexCache>>.
+AGE is a number followed by a unit of "d", "h", "m" or "s"; "0" means no limit.
+SIZE is a number of bytes optionally followed by a unit of "K", "M" or "G".
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Delims("<<", ">>").Parse(help))
+	t.Execute(cmd.errs, map[string]string{
+		"prog": cmd.prog, "name": cmd.name, "cache": cache.DefaultDir(),
+		"indexCache": client.DefaultCacheDir(),
+	})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *cacheCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	if cmd.flags.NArg() == 0 {
+		fmt.Fprintln(cmd.errs, "Error! Subcommand is not specified!")
+		cmd.usage()
+		return exitNG
+	}
+	cmd.setLogLevelByOption(opt)
+
+	c, err := cache.Open(cache.DefaultDir())
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	switch subcmd := cmd.flags.Arg(0); subcmd {
+	case "list":
+		return cmd.list(c)
+	case "prune":
+		return cmd.prune(c)
+	case "clean":
+		if err = c.Clean(); err != nil {
+			fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+			return exitNG
+		}
+		fmt.Fprintf(cmd.outs, "Removed %s\n", c.Dir())
+		if err = os.RemoveAll(client.DefaultCacheDir()); err != nil {
+			fmt.Fprintf(cmd.errs, "Error! Failed to remove index cache. %v\n", err)
+			return exitNG
+		}
+		fmt.Fprintf(cmd.outs, "Removed %s\n", client.DefaultCacheDir())
+	default:
+		fmt.Fprintf(cmd.errs, "Error! Unknown subcommand: %s\n", subcmd)
+		cmd.usage()
+		return exitNG
+	}
+
+	return exitOK
+}
+
+func (cmd *cacheCmd) list(c *cache.Cache) (exit int) {
+	switch outfmt := *cmd.option.outfmt; outfmt {
+	case outFmtJSON:
+		b, err := c.ToJSON(true)
+		if err != nil {
+			fmt.Fprintf(cmd.errs, "Error! Failed to output cached files. %v\n", err)
+			return exitNG
+		}
+		fmt.Fprintf(cmd.outs, "%s\n", b)
+	case outFmtText, "":
+		fmt.Fprint(cmd.outs, c.ToText())
+	default:
+		cmd.logger.Noticef("Unknown output format: %s", outfmt)
+		fmt.Fprint(cmd.outs, c.ToText())
+	}
+	return exitOK
+}
+
+func (cmd *cacheCmd) prune(c *cache.Cache) (exit int) {
+	maxAge, err := parseAge(*cmd.option.maxAge)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Invalid age: %s. %v\n", *cmd.option.maxAge, err)
+		return exitNG
+	}
+	maxSize, err := parseSize(*cmd.option.maxSize)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Invalid size: %s. %v\n", *cmd.option.maxSize, err)
+		return exitNG
+	}
+
+	removed, err := c.Prune(maxAge, maxSize)
+	for _, e := range removed {
+		fmt.Fprintf(cmd.outs, "Removed %s (%s)\n", e.File, e.URL)
+	}
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+	cmd.logger.Infof("Total: %s in %d files", cache.FormatSize(c.TotalSize()), len(c.Entries))
+	return exitOK
+}
+
+// parseAge parses duration string which accepts "d" for days in addition to time.ParseDuration
+func parseAge(s string) (d time.Duration, err error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseSize parses size string like "500M" into bytes. Units are powers of 1024
+func parseSize(s string) (size int64, err error) {
+	if s == "" {
+		return 0, nil
+	}
+	var unit int64 = 1
+	num := strings.TrimSuffix(strings.ToUpper(s), "B")
+	num = strings.TrimSuffix(num, "I")
+	switch {
+	case strings.HasSuffix(num, "K"):
+		unit = 1 << 10
+	case strings.HasSuffix(num, "M"):
+		unit = 1 << 20
+	case strings.HasSuffix(num, "G"):
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		num = num[:len(num)-1]
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("Negative size")
+	}
+	return int64(n * float64(unit)), nil
+}
@@ -33,8 +33,10 @@ func NewCLI(outs, errs io.Writer) *CLI {
 func (c *CLI) Run(args []string) (exit int) {
 	prog := filepath.Base(args[0])
 
-	lv.Configure(c.ErrStream, defaultLogLevel, 0)
-	common := &commonCmd{outs: c.OutStream, errs: c.ErrStream, prog: prog, name: "install"}
+	common := &commonCmd{
+		outs: c.OutStream, errs: c.ErrStream, prog: prog, name: "install",
+		logger: lv.New(c.ErrStream, defaultLogLevel, 0),
+	}
 	installer := newInstallCmd(common)
 
 	if len(args) == 1 {
@@ -45,10 +47,46 @@ func (c *CLI) Run(args []string) (exit int) {
 	switch args[1] {
 	case "install":
 		return installer.run(args[1:])
+	case "list":
+		lister := newListCmd(common)
+		lister.name = "list"
+		return lister.run(args[2:])
+	case "uninstall":
+		uninstaller := newUninstallCmd(common)
+		uninstaller.name = "uninstall"
+		return uninstaller.run(args[2:])
+	case "use":
+		user := newUseCmd(common)
+		user.name = "use"
+		return user.run(args[2:])
+	case "rollback":
+		rollbacker := newRollbackCmd(common)
+		rollbacker.name = "rollback"
+		return rollbacker.run(args[2:])
+	case "outdated":
+		checker := newOutdatedCmd(common)
+		checker.name = "outdated"
+		return checker.run(args[2:])
+	case "upgrade":
+		upgrader := newUpgradeCmd(common)
+		upgrader.name = "upgrade"
+		return upgrader.run(args[2:])
+	case "sync":
+		syncer := newSyncCmd(common)
+		syncer.name = "sync"
+		return syncer.run(args[2:])
+	case "prefetch":
+		prefetcher := newPrefetchCmd(common)
+		prefetcher.name = "prefetch"
+		return prefetcher.run(args[2:])
 	case "index":
 		lister := newIndexCmd(common)
 		lister.name = "index"
 		return lister.run(args[2:])
+	case "cache":
+		cacher := newCacheCmd(common)
+		cacher.name = "cache"
+		return cacher.run(args[2:])
 	case "new":
 		creator := newCreateCmd(common)
 		creator.name = "new"
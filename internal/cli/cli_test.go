@@ -2,6 +2,8 @@ package cli
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
@@ -21,6 +23,17 @@ type testCaseRun struct {
 // but no operation which affects filesystem
 func TestRunAll(t *testing.T) {
 	prog := "binq"
+
+	// Isolate installation registry from user's environment
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "binq-test-run.*")
+	if err != nil {
+		t.Fatalf("Error! Failed to create tempdir. %v\n", err)
+	}
+	defer os.RemoveAll(tmpdir)
+	os.Setenv("XDG_DATA_HOME", tmpdir)
+	os.Setenv("XDG_CONFIG_HOME", tmpdir)
+	os.Setenv("XDG_CACHE_HOME", tmpdir)
+
 	testCases := buildTestRunAllCases(prog)
 
 	// Run test cases
@@ -66,9 +79,12 @@ func buildTestRunAllCases(prog string) []testCaseRun {
 	invalidFlg := "--no-such-option"
 	flagError := fmt.Sprintf("Error! Parsing arguments failed. unknown flag: %s", invalidFlg)
 
+	listOutText := "Name                Version         Directory"
+	outdatedOutText := "Name                Current         Latest          Directory"
 	indexOutText := strings.TrimRight(schema.NewIndex().ToText(), "\n")
 	indexOutJSON := `{
   "items": [`
+	cacheOutText := "File                                      Size    Last Used           URL"
 
 	return []testCaseRun{
 		// Without subcommand (but install)
@@ -92,6 +108,105 @@ func buildTestRunAllCases(prog string) []testCaseRun {
 			args: []string{"install"}, exit: exitNG, outStr: "",
 			errStr: strings.Join([]string{"Error! Target is not specified!", commands["install"].helpText}, "\n"),
 		},
+		{
+			args: []string{"install", "foo", "--insecure-skip-verify", "--require-checksum"}, exit: exitNG,
+			outStr: "", errStr: "Error! --insecure-skip-verify and --require-checksum are exclusive",
+		},
+		{
+			args: []string{"install", "foo", "--no-cache", "--offline"}, exit: exitNG,
+			outStr: "", errStr: "Error! --no-cache and --offline are exclusive",
+		},
+		{
+			args: []string{"install", "foo", "--offline"}, exit: exitNG, outStr: "",
+			errStr: "Error! Required data is not cached for offline mode.",
+		},
+		{
+			args: []string{"install", "foo", "bar", "-f", "baz"}, exit: exitNG, outStr: "",
+			errStr: "Error! --file can't be used with multiple targets",
+		},
+		{
+			args: []string{"install", "foo", "--jobs", "0"}, exit: exitNG, outStr: "",
+			errStr: "Error! --jobs must be positive. Given: 0",
+		},
+		{
+			args: []string{"install", "foo", "bar", "--offline"}, exit: exitNG, outStr: "Failed foo\nFailed bar\n",
+			errStr: "[bar] Error! Required data is not cached for offline mode.",
+		},
+		{
+			args: []string{"install", "foo", "--proxy", "://proxy"}, exit: exitNG, outStr: "",
+			errStr: "Error! Invalid proxy URL: ://proxy",
+		},
+		{
+			args: []string{"install", "foo", "--ca-bundle", "no-such-file.pem"}, exit: exitNG, outStr: "",
+			errStr: "Error! Can't read CA bundle: no-such-file.pem",
+		},
+
+		// list
+		{args: []string{"list", "--help"}, exit: exitOK, outStr: "", errStr: commands["list"].helpText},
+		{args: []string{"list", invalidFlg}, exit: exitNG, outStr: "", errStr: flagError},
+		{args: []string{"list"}, exit: exitOK, outStr: listOutText, errStr: ""},
+		{args: []string{"list", "--output", "json"}, exit: exitOK, outStr: `"items": []`, errStr: ""},
+
+		// uninstall
+		{args: []string{"uninstall", "--help"}, exit: exitOK, outStr: "", errStr: commands["uninstall"].helpText},
+		{args: []string{"uninstall", invalidFlg}, exit: exitNG, outStr: "", errStr: flagError},
+		{
+			args: []string{"uninstall"}, exit: exitNG, outStr: "",
+			errStr: strings.Join([]string{"Error! NAME is not specified", commands["uninstall"].helpText}, "\n"),
+		},
+		{
+			args: []string{"uninstall", "no-such-item"}, exit: exitNG, outStr: "",
+			errStr: "Error! no-such-item is not installed",
+		},
+
+		// outdated
+		{args: []string{"outdated", "--help"}, exit: exitOK, outStr: "", errStr: commands["outdated"].helpText},
+		{args: []string{"outdated", invalidFlg}, exit: exitNG, outStr: "", errStr: flagError},
+		{args: []string{"outdated"}, exit: exitOK, outStr: outdatedOutText, errStr: ""},
+		{args: []string{"outdated", "--output", "json"}, exit: exitOK, outStr: "[]", errStr: ""},
+		{
+			args: []string{"outdated", "no-such-item"}, exit: exitNG, outStr: "",
+			errStr: "Error! Name: no-such-item / Item is not installed",
+		},
+
+		// upgrade
+		{args: []string{"upgrade", "--help"}, exit: exitOK, outStr: "", errStr: commands["upgrade"].helpText},
+		{args: []string{"upgrade", invalidFlg}, exit: exitNG, outStr: "", errStr: flagError},
+		{args: []string{"upgrade"}, exit: exitOK, outStr: "", errStr: "No need to upgrade"},
+
+		// sync
+		{args: []string{"sync", "--help"}, exit: exitOK, outStr: "", errStr: commands["sync"].helpText},
+		{args: []string{"sync", invalidFlg}, exit: exitNG, outStr: "", errStr: flagError},
+		{args: []string{"sync"}, exit: exitNG, outStr: "", errStr: "Error! Toolfile is not found in ."},
+		{
+			args: []string{"sync", "-f", "no-such-file.json"}, exit: exitNG, outStr: "",
+			errStr: "Error! Can't read Toolfile: no-such-file.json",
+		},
+
+		// prefetch
+		{args: []string{"prefetch", "--help"}, exit: exitOK, outStr: "", errStr: commands["prefetch"].helpText},
+		{args: []string{"prefetch", invalidFlg}, exit: exitNG, outStr: "", errStr: flagError},
+		{
+			args: []string{"prefetch"}, exit: exitNG, outStr: "",
+			errStr: strings.Join([]string{"Error! ITEM is not specified", commands["prefetch"].helpText}, "\n"),
+		},
+
+		// cache
+		{args: []string{"cache", "--help"}, exit: exitOK, outStr: "", errStr: commands["cache"].helpText},
+		{args: []string{"cache", invalidFlg}, exit: exitNG, outStr: "", errStr: flagError},
+		{
+			args: []string{"cache"}, exit: exitNG, outStr: "",
+			errStr: strings.Join([]string{"Error! Subcommand is not specified!", commands["cache"].helpText}, "\n"),
+		},
+		{
+			args: []string{"cache", "no-such-cmd"}, exit: exitNG, outStr: "",
+			errStr: strings.Join([]string{"Error! Unknown subcommand: no-such-cmd", commands["cache"].helpText}, "\n"),
+		},
+		{args: []string{"cache", "list"}, exit: exitOK, outStr: cacheOutText, errStr: ""},
+		{args: []string{"cache", "list", "--output", "json"}, exit: exitOK, outStr: `"entries": []`, errStr: ""},
+		{args: []string{"cache", "prune", "--max-size", "1X"}, exit: exitNG, outStr: "", errStr: "Error! Invalid size: 1X"},
+		{args: []string{"cache", "prune", "--max-age", "1y"}, exit: exitNG, outStr: "", errStr: "Error! Invalid age: 1y"},
+		{args: []string{"cache", "prune"}, exit: exitOK, outStr: "", errStr: ""},
 
 		// index
 		{args: []string{"index", "--help"}, exit: exitOK, outStr: "", errStr: commands["index"].helpText},
@@ -142,6 +257,10 @@ func buildTestRunAllCases(prog string) []testCaseRun {
 		{
 			args: []string{"verify", "no-such-file.json"}, exit: exitNG, outStr: "", errStr: "Error! Can't read item file: ",
 		},
+		{
+			args: []string{"verify", "no-such-file.json", "--algo", "sha1"}, exit: exitNG, outStr: "",
+			errStr: "Error! Unsupported algorithm: sha1",
+		},
 
 		// register
 		{args: []string{"register", "--help"}, exit: exitOK, outStr: "", errStr: commands["register"].helpText},
@@ -162,6 +281,10 @@ func buildTestRunAllCases(prog string) []testCaseRun {
 			args: []string{"register", "invalid-index-filename.json", "no-such-file.json"},
 			exit: exitNG, outStr: "", errStr: "Error! INDEX JSON filename must be \"index.json\".",
 		},
+		{
+			args: []string{"register", "index.json", "foo.json", "--sign-key", "no-such-key"},
+			exit: exitNG, outStr: "", errStr: "Error! Can't read private key file: no-such-key",
+		},
 
 		// modify
 		{args: []string{"modify", "--help"}, exit: exitOK, outStr: "", errStr: commands["modify"].helpText},
@@ -223,6 +346,41 @@ Usage:`, prog)}
 
 Syntax:`}
 
+	info["list"] = testCommandInfo{fmt.Sprintf(`Summary:
+  List items installed by %s.
+
+Usage:`, prog)}
+
+	info["uninstall"] = testCommandInfo{fmt.Sprintf(`Summary:
+  Uninstall an item installed by %s.
+
+Usage:`, prog)}
+
+	info["outdated"] = testCommandInfo{`Summary:
+  Show installed items which have newer versions on index servers.
+
+Usage:`}
+
+	info["upgrade"] = testCommandInfo{`Summary:
+  Upgrade installed items to the latest versions.
+
+Usage:`}
+
+	info["sync"] = testCommandInfo{`Summary:
+  Install items declared in project Toolfile; and uninstall ones no longer declared.
+
+Usage:`}
+
+	info["prefetch"] = testCommandInfo{`Summary:
+  Download items into caches so that they can be installed in offline mode later.
+
+Usage:`}
+
+	info["cache"] = testCommandInfo{fmt.Sprintf(`Summary:
+  List or remove files downloaded and cached by %s.
+
+Usage:`, prog)}
+
 	info["index"] = testCommandInfo{`Summary:
   List items on binq index server.
 
@@ -6,7 +6,8 @@ import (
 
 	"github.com/binqry/binq"
 	"github.com/binqry/binq/client"
-	"github.com/progrhyme/go-lv"
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/internal/config"
 	"github.com/spf13/pflag"
 )
 
@@ -30,6 +31,7 @@ type clientCmd struct {
 
 type clientOpts struct {
 	server *string
+	*httpOpts
 	*commonOpts
 }
 
@@ -51,7 +53,8 @@ func (opt *clientOpts) getServer() (svr *string) {
 
 func newClientOpts(fs *pflag.FlagSet) *clientOpts {
 	return &clientOpts{
-		server: fs.StringP("server", "s", "", "# Index Server URL"),
+		server:   fs.StringP("server", "s", "", "# Index Server URL"),
+		httpOpts: newHTTPOpts(fs),
 		commonOpts: &commonOpts{
 			help:  fs.BoolP("help", "h", false, "# Show help"),
 			logLv: fs.StringP("log-level", "L", "", "# Log level (debug,info,notice,warn,error)"),
@@ -59,22 +62,35 @@ func newClientOpts(fs *pflag.FlagSet) *clientOpts {
 	}
 }
 
-func getClient(cmd clientRunner) (clt *client.Client, err error) {
+func getClient(cmd clientRunner, httpOptions http.Options) (clt *client.Client, err error) {
 	server := *cmd.getClientOpts().getServer()
 	if server == "" {
 		server = binq.DefaultBinqServer
 	}
 	cmd.setServer(server)
 
-	level := logLevelByOption(cmd.getClientOpts())
-	logger := lv.New(cmd.getErrs(), level, 0)
+	logger := cmd.getLogger()
 	svrURL, err := url.Parse(server)
 	if err != nil {
 		fmt.Fprintf(cmd.getErrs(), "Error! URL parse failed. %v\n", err)
 		return nil, err
 	}
-	lv.Debugf("Server URL: %s", svrURL)
+	logger.Debugf("Server URL: %s", svrURL)
 
-	clt = client.NewClient(svrURL, logger)
+	if clt, err = client.NewClient(svrURL, logger, httpOptions); err != nil {
+		fmt.Fprintf(cmd.getErrs(), "Error! %v\n", err)
+		return nil, err
+	}
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(cmd.getErrs(), "Error! %v\n", err)
+		return nil, err
+	}
+	if key := cfg.GetServer(server).PublicKey; key != "" {
+		if err = clt.SetPublicKey(key); err != nil {
+			fmt.Fprintf(cmd.getErrs(), "Error! Invalid public key for server: %s. %v\n", server, err)
+			return nil, err
+		}
+	}
 	return clt, nil
 }
@@ -2,6 +2,10 @@ package cli
 
 import (
 	"io"
+	"os"
+	"strconv"
+
+	"github.com/binqry/binq"
 
 	"github.com/progrhyme/go-lv"
 	"github.com/spf13/pflag"
@@ -10,12 +14,14 @@ import (
 type runner interface {
 	getOuts() io.Writer
 	getErrs() io.Writer
+	getLogger() *lv.Logger
 }
 
 type commonCmd struct {
 	outs, errs io.Writer
 	prog, name string
 	flags      *pflag.FlagSet
+	logger     *lv.Logger
 }
 
 type flavor interface {
@@ -36,6 +42,10 @@ func (cmd *commonCmd) getErrs() io.Writer {
 	return cmd.errs
 }
 
+func (cmd *commonCmd) getLogger() *lv.Logger {
+	return cmd.logger
+}
+
 func (opt *commonOpts) getHelp() *bool {
 	return opt.help
 }
@@ -51,19 +61,25 @@ func newCommonOpts(fs *pflag.FlagSet) *commonOpts {
 	}
 }
 
-func logLevelByOption(opt flavor) (level lv.Level) {
+func (cmd *commonCmd) setLogLevelByOption(opt flavor) {
 	if *opt.getLogLevel() == "" {
-		return defaultLogLevel
+		cmd.logger.SetLevel(defaultLogLevel)
+		return
 	}
 
-	level = lv.WordToLevel(*opt.getLogLevel())
+	level := lv.WordToLevel(*opt.getLogLevel())
 	if level == 0 {
-		lv.Warnf("Unknown log level: %s. Use default", *opt.getLogLevel())
-		return defaultLogLevel
+		cmd.logger.Warnf("Unknown log level: %s. Use default", *opt.getLogLevel())
+		level = defaultLogLevel
 	}
-	return level
+	cmd.logger.SetLevel(level)
 }
 
-func setLogLevelByOption(opt flavor) {
-	lv.SetLevel(logLevelByOption(opt))
+// isOffline reports whether offline mode is enabled by flag or environment variable
+func isOffline(flag bool) bool {
+	if flag {
+		return true
+	}
+	offline, _ := strconv.ParseBool(os.Getenv(binq.EnvKeyOffline))
+	return offline
 }
@@ -5,7 +5,6 @@ import (
 	"path/filepath"
 	"text/template"
 
-	"github.com/progrhyme/go-lv"
 	"github.com/spf13/pflag"
 )
 
@@ -23,6 +22,7 @@ func newDeregisterCmd(common *commonCmd) (self *deregisterCmd) {
 	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
 	fs.SetOutput(self.errs)
 	self.option = newIndiceOpts(fs)
+	self.signKey = newSignKeyFlag(fs)
 	fs.Usage = self.usage
 	self.flags = fs
 
@@ -34,7 +34,10 @@ func (cmd *deregisterCmd) usage() {
   Deregister an Item from Local {{.prog}} Index Dataset.
 
 Usage:
-  {{.prog}} {{.name}} pato/to/root[/index.json] NAME [-y|--yes] [GENERAL_OPTIONS]
+  {{.prog}} {{.name}} pato/to/root[/index.json] NAME [--sign-key PRIVATE_KEY_FILE] [-y|--yes] \
+    [GENERAL_OPTIONS]
+
+With "--sign-key" option, Index JSON is signed. See "{{.prog}} register --help" for details.
 
 Options:
 `
@@ -61,7 +64,11 @@ func (cmd *deregisterCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitNG
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
+	if err := cmd.loadSignKey(); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
 	fileIndex, err := resolveIndexPathByArg(args[0])
 	if err != nil {
@@ -80,7 +87,7 @@ func (cmd *deregisterCmd) run(args []string) (exit int) {
 		fmt.Fprintf(cmd.errs, "Item not found in index. Name: %s, Index: %s\n", name, fileIndex)
 		return exitNG
 	}
-	lv.Noticef("Target indice: %s", indice)
+	cmd.logger.Noticef("Target indice: %s", indice)
 
 	if !idx.Remove(name) {
 		// Unexpected
@@ -98,7 +105,7 @@ func (cmd *deregisterCmd) run(args []string) (exit int) {
 	pathItem := indice.Path
 	for _, i := range idx.Items {
 		if i.Path == pathItem {
-			lv.Noticef("Item \"%s\" still refers to \"%s\"", i.Name, i.Path)
+			cmd.logger.Noticef("Item \"%s\" still refers to \"%s\"", i.Name, i.Path)
 			exists = true
 		}
 	}
@@ -109,8 +116,9 @@ func (cmd *deregisterCmd) run(args []string) (exit int) {
 		switch err {
 		case nil:
 			fmt.Fprintf(cmd.outs, "Deleted Item JSON: %s\n", pathItem)
+			removeFile(pathItem + ".sig")
 		case errFileNotFound:
-			lv.Warnf("Can't remove file: %s. Not Found", pathItem)
+			cmd.logger.Warnf("Can't remove file: %s. Not Found", pathItem)
 		default:
 			fmt.Fprintf(cmd.errs, "Error! %v\n", err)
 			return exitNG
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/binqry/binq"
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type httpOpts struct {
+	timeout, indexTimeout *time.Duration
+	proxy, caBundle       *string
+	*retryOpts
+}
+
+func newHTTPOpts(fs *pflag.FlagSet) *httpOpts {
+	return &httpOpts{
+		timeout: fs.Duration("timeout", 0,
+			fmt.Sprintf("# Timeout of HTTP request to download files (default %s)", http.DefaultOptions.Timeout)),
+		indexTimeout: fs.Duration("index-timeout", 0,
+			fmt.Sprintf("# Timeout of HTTP request to Index Server (default %s)", http.DefaultOptions.IndexTimeout)),
+		proxy:     fs.String("proxy", "", "# Proxy URL which overrides HTTP_PROXY and HTTPS_PROXY"),
+		caBundle:  fs.String("ca-bundle", "", "# PEM file of CA certificates to trust in addition to system ones"),
+		retryOpts: newRetryOpts(fs),
+	}
+}
+
+// applyHTTPOpts returns options for HTTP requests merging "http" settings in configuration file,
+// options and environment variables. Options take precedence over configuration file
+func applyHTTPOpts(opt *httpOpts) (opts http.Options, err error) {
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil {
+		return opts, err
+	}
+	if opts, err = cfg.HTTPOptions(); err != nil {
+		return opts, err
+	}
+	if err = applyRetryOpts(opt.retryOpts, &opts.Retry); err != nil {
+		return opts, err
+	}
+	if *opt.timeout > 0 {
+		opts.Timeout = *opt.timeout
+	}
+	if *opt.indexTimeout > 0 {
+		opts.IndexTimeout = *opt.indexTimeout
+	}
+	if *opt.proxy != "" {
+		opts.Proxy = *opt.proxy
+	}
+	if *opt.caBundle != "" {
+		opts.CABundle = *opt.caBundle
+	}
+	if opts.Netrc == "" {
+		opts.Netrc = http.DefaultNetrcPath()
+	}
+	// Credentials in configuration file take precedence
+	if token := os.Getenv(binq.EnvKeyGitHubToken); token != "" {
+		for _, host := range []string{"github.com", "api.github.com"} {
+			opts.Credentials = append(opts.Credentials, http.Credential{Host: host, Token: token})
+		}
+	}
+	// Detect invalid proxy URL, CA bundle or netrc file before any request
+	if _, err = http.NewClient(opts); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
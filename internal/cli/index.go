@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"text/template"
 
-	"github.com/progrhyme/go-lv"
 	"github.com/spf13/pflag"
 )
 
@@ -68,9 +67,14 @@ func (cmd *indexCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitOK
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
-	clt, err := getClient(cmd)
+	clt, err := getClient(cmd, httpOptions)
 	if err != nil {
 		return exitNG
 	}
@@ -91,7 +95,7 @@ func (cmd *indexCmd) run(args []string) (exit int) {
 	case outFmtText, "":
 		fmt.Fprint(cmd.outs, index.ToText())
 	default:
-		lv.Noticef("Unknown output format: %s", *opt.outfmt)
+		cmd.logger.Noticef("Unknown output format: %s", *opt.outfmt)
 		fmt.Fprint(cmd.outs, index.ToText())
 	}
 
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/signature"
 	"github.com/binqry/binq/schema"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/pflag"
@@ -19,6 +20,8 @@ type indiceRunner interface {
 	confirmRunner
 	getPrevRawIndex() []byte
 	setPrevRawIndex([]byte)
+	signFile(string) error
+	signRewrittenFile(string) error
 }
 
 type indiceFlavor interface {
@@ -27,6 +30,8 @@ type indiceFlavor interface {
 
 type indiceCmd struct {
 	prevRawIndex []byte
+	signKey      *string
+	signer       *signature.PrivateKey
 	*confirmCmd
 }
 
@@ -38,6 +43,59 @@ func (cmd *indiceCmd) setPrevRawIndex(b []byte) {
 	cmd.prevRawIndex = b
 }
 
+func newSignKeyFlag(fs *pflag.FlagSet) (signKey *string) {
+	return fs.String("sign-key", "", "# Private key file to sign Index and Item JSON")
+}
+
+// loadSignKey reads private key file specified by option
+func (cmd *indiceCmd) loadSignKey() (err error) {
+	if cmd.signKey == nil || *cmd.signKey == "" {
+		return nil
+	}
+	raw, _err := ioutil.ReadFile(*cmd.signKey)
+	if _err != nil {
+		return erron.Errorwf(_err, "Can't read private key file: %s", *cmd.signKey)
+	}
+	if cmd.signer, _err = signature.ParsePrivateKey(string(raw)); _err != nil {
+		return erron.Errorwf(_err, "Invalid private key: %s", *cmd.signKey)
+	}
+	return nil
+}
+
+// signFile writes detached signature of file into "<file>.sig" when private key is loaded
+func (cmd *indiceCmd) signFile(file string) (err error) {
+	if cmd.signer == nil {
+		return nil
+	}
+	content, _err := ioutil.ReadFile(file)
+	if _err != nil {
+		return erron.Errorwf(_err, "Can't read file to sign: %s", file)
+	}
+	sigFile := file + ".sig"
+	if _err = ioutil.WriteFile(sigFile, cmd.signer.Sign(content), 0644); _err != nil {
+		return erron.Errorwf(_err, "Can't write signature: %s", sigFile)
+	}
+	fmt.Fprintf(cmd.outs, "Signed %s\n", file)
+	return nil
+}
+
+// signRewrittenFile works like signFile for file whose content is just rewritten. Without private
+// key, existing signature of the file is removed because it no longer matches the content
+func (cmd *indiceCmd) signRewrittenFile(file string) (err error) {
+	if cmd.signer != nil {
+		return cmd.signFile(file)
+	}
+	sigFile := file + ".sig"
+	if _, _err := os.Stat(sigFile); _err != nil {
+		return nil
+	}
+	if _err := os.Remove(sigFile); _err != nil {
+		return erron.Errorwf(_err, "Can't remove stale signature: %s", sigFile)
+	}
+	cmd.logger.Warnf("Removed %s which no longer matches. Specify --sign-key to sign it", sigFile)
+	return nil
+}
+
 func newIndiceOpts(fs *pflag.FlagSet) (opt *confirmOpts) {
 	return &confirmOpts{
 		yes:        fs.BoolP("yes", "y", false, "# Update Index data without confirmation"),
@@ -102,7 +160,7 @@ func writeNewIndex(cmd indiceRunner, idx *schema.Index, fileIndex string) (err e
 	}
 	if diff == "" {
 		fmt.Fprintln(cmd.getErrs(), "Index has no change")
-		return nil
+		return cmd.signFile(fileIndex)
 	}
 
 	yes := *(cmd.getConfirmOpts().getYes())
@@ -120,7 +178,11 @@ func writeNewIndex(cmd indiceRunner, idx *schema.Index, fileIndex string) (err e
 		}
 	}
 
-	return writeFile(fileIndex, newRawIndex, func() {
+	err = writeFile(fileIndex, newRawIndex, func() {
 		fmt.Fprintf(cmd.getOuts(), "Saved %s\n", fileIndex)
 	})
+	if err != nil {
+		return err
+	}
+	return cmd.signRewrittenFile(fileIndex)
 }
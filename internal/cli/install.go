@@ -1,13 +1,20 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"text/template"
 
 	"github.com/binqry/binq"
+	"github.com/binqry/binq/client"
 	"github.com/binqry/binq/install"
-	"github.com/progrhyme/go-lv"
+	"github.com/binqry/binq/install/cache"
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/config"
+	"github.com/binqry/binq/internal/xdg"
 	"github.com/spf13/pflag"
 )
 
@@ -17,8 +24,13 @@ type installCmd struct {
 }
 
 type installOpts struct {
-	target, directory, file, server *string
-	noExtract, noExec               *bool
+	target, directory, file, server, lockfile    *string
+	subdir                                       *string
+	noExtract, noExec, skipVerify, requireChksum *bool
+	skipSignature, noCache, offline, versioned   *bool
+	jobs, strip                                  *int
+	include, exclude                             *[]string
+	*httpOpts
 	*commonOpts
 }
 
@@ -28,13 +40,26 @@ func newInstallCmd(common *commonCmd) (self *installCmd) {
 	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
 	fs.SetOutput(self.errs)
 	self.option = &installOpts{
-		target:     fs.StringP("target", "t", "", "# Target Item (Name or URL)"),
-		directory:  fs.StringP("directory", "d", "", "# Output Directory"),
-		file:       fs.StringP("file", "f", "", "# Output File name"),
-		server:     fs.StringP("server", "s", "", "# Index Server URL"),
-		noExtract:  fs.BoolP("no-extract", "z", false, "# Don't extract archive"),
-		noExec:     fs.BoolP("no-exec", "X", false, "# Don't care for executable files"),
-		commonOpts: newCommonOpts(fs),
+		target:        fs.StringP("target", "t", "", "# Target Item (Name or URL)"),
+		directory:     fs.StringP("directory", "d", "", "# Output Directory"),
+		file:          fs.StringP("file", "f", "", "# Output File name"),
+		server:        fs.StringP("server", "s", "", "# Index Server URL"),
+		lockfile:      fs.StringP("lockfile", "l", "", "# Lockfile to pin resolved URL and checksum"),
+		noExtract:     fs.BoolP("no-extract", "z", false, "# Don't extract archive"),
+		noExec:        fs.BoolP("no-exec", "X", false, "# Don't care for executable files"),
+		strip:         fs.Int("strip-components", 0, "# Strip N leading components of paths in archive with --no-exec"),
+		subdir:        fs.String("subdir", "", "# Install only this subdirectory of archive with --no-exec"),
+		skipVerify:    fs.Bool("insecure-skip-verify", false, "# Don't verify checksum (insecure)"),
+		requireChksum: fs.Bool("require-checksum", false, "# Refuse to install without checksum"),
+		skipSignature: fs.Bool("insecure-skip-signature", false, "# Don't verify signature of downloaded file (insecure)"),
+		noCache:       fs.Bool("no-cache", false, "# Don't use caches"),
+		offline:       fs.Bool("offline", false, "# Install only from caches without network access"),
+		versioned:     fs.Bool("versioned", false, "# Install into per-version directory and link files from OUTPUT_DIR"),
+		jobs:          fs.IntP("jobs", "j", 4, "# Max number of items installed in parallel"),
+		include:       fs.StringSlice("include", nil, "# Glob patterns of files in archive to install"),
+		exclude:       fs.StringSlice("exclude", nil, "# Glob patterns of files in archive not to install"),
+		httpOpts:      newHTTPOpts(fs),
+		commonOpts:    newCommonOpts(fs),
 	}
 	fs.Usage = func() { self.usage(true) }
 	self.flags = fs
@@ -48,11 +73,13 @@ func (cmd *installCmd) usage(subcommand bool) {
   Download & extract binary or archive via HTTP; then locate executable files into target directory.
 
 Syntax:
-  {{.prog}} [{{.name}}] [-t|--target] SOURCE[@VERSION]
-    [-d|--dir OUTPUT_DIR] [-f|--file OUTFILE] \
-    [-s|--server SERVER] \
-    [-z|--no-extract] [-X|--no-exec] \
-    [GENERAL_OPTIONS]
+  {{.prog}} [{{.name}}] [-t|--target] SOURCE[@VERSION] [SOURCE[@VERSION]...] \
+    [-d|--dir OUTPUT_DIR] [-f|--file OUTFILE] [-j|--jobs N] \
+    [-s|--server SERVER] [-l|--lockfile LOCKFILE] \
+    [-z|--no-extract] [-X|--no-exec [--strip-components N] [--subdir PATH]] \
+    [--include PATTERN...] [--exclude PATTERN...] \
+    [--insecure-skip-verify|--require-checksum] [--insecure-skip-signature] [--no-cache|--offline] \
+    [--versioned] [GENERAL_OPTIONS]
 
 Examples:
   # With full URL
@@ -71,11 +98,95 @@ Examples:
   export BINQ_SERVER="https://your-index-server/"
   binq jq
 
+  # Pin resolved URL and checksum; and install the same content later
+  {{.prog}} jq -l binq.lock
+
+  # Install multiple items in parallel
+  {{.prog}} {{.name}} jq peco kustomize@3.8.0 -d path/to/bin -j 2
+
+When multiple items are specified, at most "--jobs" items are installed in parallel. Log lines of
+each item are prefixed by its name; and the result of each item is printed at the end.
+"--file" option can't be used with multiple items.
+
+From an archive, all executable files are installed by default. "files" in Item Manifest, or
+"--include" option which overrides it, selects files to install by glob patterns like
+"*/bin/kubectl" or "LICENSE". A pattern without "/" matches base name of files at any depth; and
+otherwise the path from archive root. Selected executables are put into OUTPUT_DIR; and other
+files into {{.share}}/NAME/. "--exclude" option skips files matching the patterns.
+
+With "--no-exec" option, the whole extracted archive is placed into OUTPUT_DIR. "--subdir" option
+installs only the subdirectory of the archive; and "--strip-components" option removes N leading
+components of paths like "tar --strip-components". Then the directory is placed as OUTFILE, or
+the item name. "subdir" and "strip-components" in Item Manifest work in the same way; and the
+options override them. "--strip-components 0" installs the archive without stripping. Example:
+
+  # Install Go SDK into path/to/sdk/go
+  {{.prog}} https://golang.org/dl/go1.15.2.linux-amd64.tar.gz -X --strip-components 1 \
+    -d path/to/sdk -f go
+
+Shell completions, man pages and licenses declared by "aux-files" in Item Manifest are installed
+into XDG standard locations under {{.dataHome}}; e.g. man pages into man/manN/. The locations are
+changed by "dirs" section in {{.config}} keyed by role like:
+
+  {"dirs": {"bash-completion": "/path/to/completions", "zsh-completion": "/path/to/site-functions",
+    "fish-completion": "/path/to/vendor_completions.d", "man": "/path/to/man", "license": "/path/to/licenses"}}
+
+They are recorded with executables and removed by "{{.prog}} uninstall".
+
+Installation fails when checksum of downloaded file differs from the one in Item Manifest or
+Lockfile. When Item Manifest declares "signature-url-format" and "public-key", detached signature
+of downloaded file is also verified before extraction.
+"--insecure-skip-verify" option disables checksum verification; and "--insecure-skip-signature"
+disables signature verification.
+
+When public key of index server is configured in {{.config}}, signatures of Index JSON and Item
+JSON on the server are verified. Configuration example:
+
+  {"servers": {"https://your-index-server/": {"public-key": "BASE64_ENCODED_ED25519_PUBLIC_KEY"}}}
+
+Downloaded files are cached in {{.cache}} and reused when their checksums are known.
+Index and Item JSON are cached in {{.indexCache}}.
+Interrupted download is resumed on next run if the server supports Range requests.
+"--no-cache" option disables the caches. Run "{{.prog}} cache -h" to manage the cache.
+
+HTTP requests are retried on transient errors like connection reset, 429 and 5xx responses.
+"--retry" and "--retry-delay" options, or environment variables {{.retry}} and {{.retryDelay}}
+change the number of attempts and the wait before retry. "--retry 0" disables retry.
+Timeouts, proxy and CA bundle are set by "--timeout", "--index-timeout", "--proxy" and
+"--ca-bundle" options, or "http" section in {{.config}} like:
+
+  {"http": {"timeout": "10m", "index-timeout": "30s", "proxy": "http://proxy.example.com:8080",
+    "ca-bundle": "/path/to/ca.pem", "user-agent-suffix": "corp-ci"}}
+
+Other keys are "dial-timeout", "tls-handshake-timeout" and "response-header-timeout".
+
+Credentials for hosts are read from "credentials" in "http" section, ~/.netrc, and environment
+variable {{.githubToken}} which is sent to github.com and api.github.com. Credentials are never
+sent to other hosts on redirect. Configuration example:
+
+  {"http": {"credentials": {"artifactory.example.com": {"username": "USER", "password": "PASS"},
+    "files.example.com": {"token": "BEARER_TOKEN"}}}}
+
+With "--versioned" option, each version of an item is installed into {{.pkgs}}/NAME/VERSION/;
+and OUTPUT_DIR has only symbolic links to the files of the active version. Installed versions are
+kept; "{{.prog}} use NAME@VERSION" switches the links and "{{.prog}} rollback NAME" goes back to the
+previously active version. Only items on index server can be installed in this layout.
+
+In offline mode, which is also enabled by environment variable {{.offline}}=true, items are
+resolved and installed only from the caches. Installation fails when required data is not cached.
+Run "{{.prog}} prefetch" beforehand to populate the caches.
+
 Options:
 `
 
 		t := template.Must(template.New("usage").Parse(help))
-		t.Execute(cmd.errs, map[string]string{"prog": cmd.prog, "name": cmd.name})
+		t.Execute(cmd.errs, map[string]string{
+			"prog": cmd.prog, "name": cmd.name, "config": config.DefaultPath(),
+			"cache": cache.DefaultDir(), "indexCache": client.DefaultCacheDir(),
+			"offline": binq.EnvKeyOffline, "retry": binq.EnvKeyRetry, "retryDelay": binq.EnvKeyRetryDelay,
+			"githubToken": binq.EnvKeyGitHubToken, "pkgs": install.DefaultPkgsDir(),
+			"share": install.DefaultShareDir(), "dataHome": xdg.DataHome(),
+		})
 
 		cmd.flags.PrintDefaults()
 	} else {
@@ -91,6 +202,14 @@ Usage:
 
 Available Commands:
   install (Default)  # Install binary or archive Item
+  list               # List installed Items
+  uninstall          # Uninstall an installed Item
+  use                # Switch active version of an Item installed with --versioned
+  rollback           # Go back to previous version of an Item installed with --versioned
+  outdated           # Show installed Items which have newer versions
+  upgrade            # Upgrade installed Items to the latest versions
+  sync               # Install Items declared in project Toolfile
+  prefetch           # Download Items into caches for offline installation
   index              # List Items on Index Server
   new                # Create Item Manifest
   revise             # Add/Edit/Delete a version in Item Manifest
@@ -98,6 +217,7 @@ Available Commands:
   register           # Register or Update Item Manifest onto Local Index Dataset
   modify             # Modify Item properties on Local Index Dataset
   deregister         # Deregister Item from Local Index
+  cache              # List or remove cached downloads
   self-upgrade       # Upgrade {{.prog}} binary itself
   version            # Show {{.prog}} version
 
@@ -136,6 +256,15 @@ func (cmd *installCmd) run(args []string) (exit int) {
 		return exitNG
 	}
 
+	if *opt.skipVerify && *opt.requireChksum {
+		fmt.Fprintln(cmd.errs, "Error! --insecure-skip-verify and --require-checksum are exclusive")
+		return exitNG
+	}
+	if *opt.noCache && *opt.offline {
+		fmt.Fprintln(cmd.errs, "Error! --no-cache and --offline are exclusive")
+		return exitNG
+	}
+
 	mode := install.ModeDefault
 	if *opt.noExtract {
 		mode = mode ^ install.ModeExtract
@@ -147,31 +276,144 @@ func (cmd *installCmd) run(args []string) (exit int) {
 		mode = install.ModeDLOnly
 	}
 
-	var source string
-	if *opt.target != "" {
-		source = *opt.target
-	} else {
-		source = cmd.flags.Arg(0)
+	targets := uniqueTargets(append([]string{*opt.target}, cmd.flags.Args()...))
+	if len(targets) > 1 && *opt.file != "" {
+		fmt.Fprintln(cmd.errs, "Error! --file can't be used with multiple targets")
+		return exitNG
+	}
+	if *opt.jobs < 1 {
+		fmt.Fprintf(cmd.errs, "Error! --jobs must be positive. Given: %d\n", *opt.jobs)
+		return exitNG
+	}
+	strip, err := stripComponentsOpt(cmd.flags, opt.strip)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
 	}
-	setLogLevelByOption(opt)
 
 	dir := os.Getenv(binq.EnvKeyBinDir)
 	if *opt.directory != "" {
 		dir = *opt.directory
 	}
 	opts := install.RunOption{
-		Mode:      mode,
-		Source:    source,
-		DestDir:   dir,
-		DestFile:  *opt.file,
-		Output:    cmd.errs,
-		LogLevel:  lv.GetLevel(),
-		ServerURL: *opt.server,
-	}
-	if err := install.Run(opts); err != nil {
-		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
-		return exitNG
+		Mode:            mode,
+		DestDir:         dir,
+		DestFile:        *opt.file,
+		Output:          cmd.errs,
+		LogLevel:        cmd.logger.GetLevel(),
+		ServerURL:       *opt.server,
+		RegistryPath:    registry.DefaultPath(),
+		ConfigPath:      config.DefaultPath(),
+		LockfilePath:    *opt.lockfile,
+		SkipVerify:      *opt.skipVerify,
+		SkipSignature:   *opt.skipSignature,
+		RequireChecksum: *opt.requireChksum,
+		HTTPOptions:     httpOptions,
+		Include:         *opt.include,
+		Exclude:         *opt.exclude,
+		StripComponents: strip,
+		Subdir:          *opt.subdir,
+	}
+	if *opt.versioned {
+		opts.PkgsDir = install.DefaultPkgsDir()
+	}
+	if !*opt.noCache {
+		opts.CacheDir = cache.DefaultDir()
+		opts.IndexCacheDir = client.DefaultCacheDir()
+		opts.Offline = isOffline(*opt.offline)
 	}
 
-	return exitOK
+	ctx, stop := signalContext()
+	defer stop()
+	if len(targets) == 1 {
+		opts.Source = targets[0]
+		opts.ProgressFunc = newProgressFunc(cmd.errs)
+		if err = install.New(opts).Run(ctx); err != nil {
+			fmt.Fprintln(cmd.errs, installErrorMessage(err))
+			return exitNG
+		}
+		return exitOK
+	}
+	return cmd.installAll(ctx, targets, opts, *opt.jobs)
+}
+
+// installAll installs targets by at most jobs workers in parallel. Log output of each target is
+// prefixed by its name. Results are summarized at the end
+func (cmd *installCmd) installAll(ctx context.Context, targets []string, base install.RunOption, jobs int) (exit int) {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = make([]bool, len(targets))
+		queue  = make(chan int)
+	)
+	for n := 0; n < jobs && n < len(targets); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				w := &prefixWriter{w: cmd.errs, mu: &mu, prefix: fmt.Sprintf("[%s] ", targets[i])}
+				opts := base
+				opts.Source = targets[i]
+				opts.Output = w
+				opts.ProgressFunc = newProgressFunc(w)
+				if err := install.New(opts).Run(ctx); err != nil {
+					fmt.Fprintln(w, installErrorMessage(err))
+					failed[i] = true
+				}
+			}
+		}()
+	}
+	for i := range targets {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	exit = exitOK
+	for i, target := range targets {
+		if failed[i] {
+			fmt.Fprintf(cmd.outs, "Failed %s\n", target)
+			exit = exitNG
+		} else {
+			fmt.Fprintf(cmd.outs, "Installed %s\n", target)
+		}
+	}
+	return exit
+}
+
+// installErrorMessage returns error message for the failure of installation
+func installErrorMessage(err error) (msg string) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return fmt.Sprintf("Error! Installation is interrupted. %v", err)
+	case errors.Is(err, install.ErrChecksumMismatch):
+		return fmt.Sprintf("Error! Downloaded file may be corrupt or tampered. %v", err)
+	case errors.Is(err, install.ErrSignatureInvalid):
+		return fmt.Sprintf("Error! Signature of downloaded file is invalid. %v", err)
+	case errors.Is(err, install.ErrNotCached):
+		return fmt.Sprintf("Error! Required data is not cached for offline mode. %v", err)
+	case errors.Is(err, install.ErrChecksumNotProvided):
+		return fmt.Sprintf("Error! Checksum is required but not provided. %v", err)
+	default:
+		return fmt.Sprintf("Error! %v", err)
+	}
+}
+
+// uniqueTargets removes empty and duplicate targets keeping the order
+func uniqueTargets(targets []string) (uniq []string) {
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		uniq = append(uniq, t)
+	}
+	return uniq
 }
@@ -2,14 +2,19 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"strings"
 
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/client/http"
 	"github.com/binqry/binq/internal/erron"
 	"github.com/binqry/binq/schema/item"
 	"github.com/mattn/go-isatty"
+	"github.com/progrhyme/go-lv"
 )
 
 func readAndDecodeItemJSONFile(file string) (raw []byte, obj *item.Item, err error) {
@@ -26,6 +31,38 @@ func readAndDecodeItemJSONFile(file string) (raw []byte, obj *item.Item, err err
 	return raw, obj, nil
 }
 
+// importChecksums fetches checksum file of rev published by upstream and merges its content into
+// checksums of the version in obj. The request is made by httpOptions and aborted by ctx
+func importChecksums(
+	ctx context.Context, obj *item.Item, rev *item.ItemRevision, param item.FormatParam,
+	httpOptions http.Options, logger lv.Standard,
+) (changed bool, err error) {
+	sumURL, err := rev.GetChecksumURL(param)
+	if err != nil {
+		return false, err
+	}
+	if sumURL == "" {
+		return false, fmt.Errorf("\"checksum-url-format\" is not defined. Version: %s", rev.Version)
+	}
+	// Used for checksum file which has no file name
+	var file string
+	if urlStr, _err := rev.GetURL(param); _err == nil && urlStr != "" {
+		file = path.Base(urlStr)
+	}
+
+	// Checksum file is not on Index Server
+	clt, err := client.NewClient(nil, logger, httpOptions)
+	if err != nil {
+		return false, err
+	}
+	sums, err := clt.GetChecksumsContext(ctx, sumURL, file)
+	if err != nil {
+		return false, err
+	}
+	logger.Debugf("Imported checksums: %+v", sums)
+	return obj.MergeRevisionChecksums(rev.Version, sums), nil
+}
+
 func updateItemJSON(cmd confirmRunner, obj *item.Item, file string, prev []byte) (exit int) {
 	updated, err := obj.Print(true)
 	if err != nil {
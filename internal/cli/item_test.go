@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -32,6 +35,123 @@ func TestItemAll(t *testing.T) {
 	}
 }
 
+// TestImportSums checks that checksum file is fetched with HTTP options of the command
+func TestImportSums(t *testing.T) {
+	prog := "binq"
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%064d  foo-0.1.0.zip\n", 0)
+	}))
+	defer ts.Close()
+
+	tmpdir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpdir)
+	t.Setenv("XDG_CACHE_HOME", tmpdir)
+	caBundle := filepath.Join(tmpdir, "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := ioutil.WriteFile(caBundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(tmpdir, "foo.json")
+	itemJSON := fmt.Sprintf(`{
+  "meta": {
+    "url-format": "https://example.com/foo-{{.Version}}.zip",
+    "checksum-url-format": "%s/SHA256SUMS"
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0"
+    }
+  ]
+}
+`, ts.URL)
+	if err := ioutil.WriteFile(file, []byte(itemJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []testCaseRun{
+		{
+			// Server certificate is not trusted without CA bundle
+			args: []string{"revise", file, "0.1.0", "--import-sums", "--retry", "1", "-y"}, exit: exitNG,
+			outStr: "", errStr: "Error! Failed to import checksums.",
+		},
+		{
+			args: []string{"revise", file, "0.1.0", "--import-sums", "--ca-bundle", caBundle, "-y"}, exit: exitOK,
+			outStr: "Updated " + file, errStr: "",
+			check: func(t *testing.T) {
+				raw, err := ioutil.ReadFile(file)
+				if err != nil || !strings.Contains(string(raw), `"file": "foo-0.1.0.zip"`) {
+					t.Errorf("Checksums are not imported. Got: %s, Error: %v", raw, err)
+				}
+			},
+		},
+	}
+	for _, tt := range testCases {
+		name := fmt.Sprintf("%d:%s", tt.exit, strings.Join(tt.args[2:], "_"))
+		t.Run(name, func(t *testing.T) { subtestRun(t, prog, tt) })
+	}
+}
+
+// TestReviseStripComponents checks that explicit 0 of "--strip-components" is written into the
+// version to override the one in meta
+func TestReviseStripComponents(t *testing.T) {
+	prog := "binq"
+	file := filepath.Join(t.TempDir(), "sdk.json")
+	itemJSON := `{
+  "meta": {
+    "url-format": "https://example.com/sdk-{{.Version}}.zip",
+    "strip-components": 1
+  },
+  "latest": {
+    "version": "0.1.0"
+  },
+  "versions": [
+    {
+      "version": "0.1.0"
+    }
+  ]
+}
+`
+	if err := ioutil.WriteFile(file, []byte(itemJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []testCaseRun{
+		{
+			args: []string{"revise", file, "0.2.0", "--strip-components", "-1", "-y"}, exit: exitNG,
+			outStr: "", errStr: "Error! --strip-components must not be negative",
+		},
+		{
+			args: []string{"revise", file, "0.2.0", "--strip-components", "0", "-y"}, exit: exitOK,
+			outStr: "Updated " + file, errStr: "",
+			check: func(t *testing.T) {
+				raw, err := ioutil.ReadFile(file)
+				want := `"version": "0.2.0",
+      "strip-components": 0`
+				if err != nil || !strings.Contains(string(raw), want) {
+					t.Errorf("strip-components is not written. Got: %s, Error: %v", raw, err)
+				}
+			},
+		},
+		{
+			args: []string{"revise", file, "0.3.0", "-y"}, exit: exitOK,
+			outStr: "Updated " + file, errStr: "",
+			check: func(t *testing.T) {
+				raw, err := ioutil.ReadFile(file)
+				if err != nil || strings.Count(string(raw), `"strip-components"`) != 2 {
+					t.Errorf("strip-components is written without the option. Got: %s, Error: %v", raw, err)
+				}
+			},
+		},
+	}
+	for _, tt := range testCases {
+		name := fmt.Sprintf("%d:%s", tt.exit, strings.Join(tt.args[2:], "_"))
+		t.Run(name, func(t *testing.T) { subtestRun(t, prog, tt) })
+	}
+}
+
 func getTestItemProperties(outDir string) (props map[string]string) {
 	return map[string]string{
 		"miniFile":            filepath.Join(outDir, "minimal.json"),
@@ -119,6 +239,8 @@ func buildTestReviseCases(stash map[string]string) (tests []testCaseRun) {
 				"sumKey1":       filepath.Base(stash["miniFile"]),
 				"sumVal1":       "dummy",
 				"urlArg":        "https://example.com/revised/download/",
+				"sigURLArg":     "https://example.com/revised/download/{{.File}}.minisig",
+				"publicKeyArg":  "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3",
 				"repKey1":       "amd64",
 				"repVal1":       "x86_64",
 				"extKey1":       "default",
@@ -192,6 +314,12 @@ func buildTestReviseCaseWithParams(params testItemParams) (tc testCaseRun) {
 	if params.props["urlArg"] != "" {
 		args = append(args, []string{"--url", params.props["urlArg"]}...)
 	}
+	if params.props["sigURLArg"] != "" {
+		args = append(args, []string{"--sig-url", params.props["sigURLArg"]}...)
+	}
+	if params.props["publicKeyArg"] != "" {
+		args = append(args, []string{"--public-key", params.props["publicKeyArg"]}...)
+	}
 	if params.props["repKey1"] != "" {
 		replaceArg := fmt.Sprintf("%s:%s", kv["repKey1"], kv["repVal1"])
 		args = append(args, []string{"--replace", replaceArg}...)
@@ -305,6 +433,8 @@ const testRevisedRichJSONFormat = `{
         }
       ],
       "url-format": "<<.urlArg>>",
+      "signature-url-format": "<<.sigURLArg>>",
+      "public-key": "<<.publicKeyArg>>",
       "replacements": {
         "<<.repKey1>>": "<<.repVal1>>"
       },
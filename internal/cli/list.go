@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/binqry/binq/install/registry"
+	"github.com/spf13/pflag"
+)
+
+type listCmd struct {
+	*commonCmd
+	option *listOpts
+}
+
+type listOpts struct {
+	outfmt *string
+	*commonOpts
+}
+
+func newListCmd(common *commonCmd) (self *listCmd) {
+	self = &listCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &listOpts{
+		outfmt:     fs.StringP("output", "o", "", "# Output format (text,json)"),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *listCmd) usage() {
+	const help = `Summary:
+  List items installed by <<.prog>>.
+
+Usage:
+  <<.prog>> <<.name>> [-o|--output FORMAT] [GENERAL_OPTIONS]
+
+Installed items are recorded in <<.registry>>.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Delims("<<", ">>").Parse(help))
+	t.Execute(cmd.errs, map[string]string{
+		"prog": cmd.prog, "name": cmd.name, "registry": registry.DefaultPath(),
+	})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *listCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	cmd.setLogLevelByOption(opt)
+
+	reg, err := registry.Load(registry.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	switch *opt.outfmt {
+	case outFmtJSON:
+		b, err := reg.ToJSON(true)
+		if err != nil {
+			fmt.Fprintf(cmd.errs, "Error! Failed to output installed items. %v\n", err)
+			return exitNG
+		}
+		fmt.Fprintf(cmd.outs, "%s\n", b)
+	case outFmtText, "":
+		fmt.Fprint(cmd.outs, reg.ToText())
+	default:
+		cmd.logger.Noticef("Unknown output format: %s", *opt.outfmt)
+		fmt.Fprint(cmd.outs, reg.ToText())
+	}
+
+	return exitOK
+}
@@ -6,7 +6,6 @@ import (
 	"path/filepath"
 	"text/template"
 
-	"github.com/progrhyme/go-lv"
 	"github.com/spf13/pflag"
 )
 
@@ -38,6 +37,7 @@ func newModifyCmd(common *commonCmd) (self *modifyCmd) {
 		path:        fs.StringP("path", "p", "", "# New Path for the Item"),
 		confirmOpts: newIndiceOpts(fs),
 	}
+	self.signKey = newSignKeyFlag(fs)
 	fs.Usage = self.usage
 	self.flags = fs
 
@@ -50,7 +50,8 @@ func (cmd *modifyCmd) usage() {
 
 Usage:
   {{.prog}} {{.name}} pato/to/root[/index.json] NAME \
-    [-n|--name NEW_NAME] [-p|--path PATH] [-y|--yes] [GENERAL_OPTIONS]
+    [-n|--name NEW_NAME] [-p|--path PATH] [--sign-key PRIVATE_KEY_FILE] [-y|--yes] \
+    [GENERAL_OPTIONS]
 
 Example:
   {{.prog}} {{.name}} index-root-dir foo -n bar -p example.com/bar[/index.json]
@@ -58,6 +59,9 @@ Example:
 The command above modify "foo" entry in Index, with new name and path.
 If you want to update the content of an Item, use "{{.prog}} register" command.
 
+With "--sign-key" option, Index JSON and moved Item JSON are signed.
+See "{{.prog}} register --help" for details.
+
 Options:
 `
 
@@ -83,7 +87,11 @@ func (cmd *modifyCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitNG
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
+	if err := cmd.loadSignKey(); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
 	fileIndex, err := resolveIndexPathByArg(args[0])
 	if err != nil {
@@ -102,7 +110,7 @@ func (cmd *modifyCmd) run(args []string) (exit int) {
 		fmt.Fprintf(cmd.errs, "Item not found in index. Name: %s, Index: %s\n", name, fileIndex)
 		return exitNG
 	}
-	lv.Noticef("Target indice: %s", indice)
+	cmd.logger.Noticef("Target indice: %s", indice)
 
 	newName, newPathItem := *opt.newName, *opt.path
 
@@ -151,6 +159,13 @@ func (cmd *modifyCmd) run(args []string) (exit int) {
 		return exitNG
 	}
 	fmt.Fprintf(cmd.outs, "Moved Item JSON: %s => %s\n", oldPathItem, newPathItem)
+	if _, err = os.Stat(oldPathItem + ".sig"); err == nil {
+		os.Rename(oldPathItem+".sig", newPathItem+".sig")
+	}
+	if err = cmd.signFile(newPathItem); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
 	return exitOK
 }
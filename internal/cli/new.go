@@ -16,7 +16,8 @@ type createCmd struct {
 }
 
 type createOpts struct {
-	version, replacements, extensions, renameFiles, file *string
+	version, replacements, extensions, renameFiles, files, auxFiles, subdir, sumURL, sigURL, publicKey, file *string
+	strip                                                                                                    *int
 	*commonOpts
 }
 
@@ -31,6 +32,13 @@ func newCreateCmd(common *commonCmd) (self *createCmd) {
 		replacements: fs.StringP("replace", "r", "", "# JSON parameter for \"replacements\""),
 		extensions:   fs.StringP("ext", "e", "", "# JSON parameter for \"extensions\""),
 		renameFiles:  fs.StringP("rename", "R", "", "# JSON parameter for \"rename-files\""),
+		files:        fs.String("files", "", "# JSON parameter for \"files\". Comma separated glob patterns"),
+		auxFiles:     fs.String("aux-files", "", "# JSON parameter for \"aux-files\""),
+		strip:        fs.Int("strip-components", 0, "# JSON parameter for \"strip-components\""),
+		subdir:       fs.String("subdir", "", "# JSON parameter for \"subdir\""),
+		sumURL:       fs.StringP("sum-url", "c", "", "# JSON parameter for \"checksum-url-format\""),
+		sigURL:       fs.String("sig-url", "", "# JSON parameter for \"signature-url-format\""),
+		publicKey:    fs.String("public-key", "", "# JSON parameter for \"public-key\""),
 		commonOpts:   newCommonOpts(fs),
 	}
 	fs.Usage = self.usage
@@ -46,6 +54,9 @@ func (cmd *createCmd) usage() {
 Usage:
   <<.prog>> <<.name>> URL_FORMAT [-v|--version VERSION] [-f|--file OUTPUT_FILE] \
     [-r|--replace REPLACEMENTS] [-e|--ext EXTENSIONS] [-R|--rename RENAME_FILES] \
+    [--files FILES] [--aux-files AUX_FILES] \
+    [--strip-components N] [--subdir SUBDIR] \
+    [-c|--sum-url CHECKSUM_URL_FORMAT] [--sig-url SIGNATURE_URL_FORMAT --public-key PUBLIC_KEY] \
     [GENERAL_OPTIONS]
 
 Examples:
@@ -81,6 +92,10 @@ The command above generates JSON like this:
 
 This is a valid JSON with which <<.prog>> download and install the archive "mdbook".
 
+PUBLIC_KEY is a minisign public key or a base64/hex encoded raw Ed25519 public key.
+When both SIGNATURE_URL_FORMAT and PUBLIC_KEY are specified, <<.prog>> verifies detached signature
+of downloaded file on installation.
+
 Options:
 `
 
@@ -106,27 +121,42 @@ func (cmd *createCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitNG
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
 
 	var urlFormat string
-	var replacements, extensions, renameFiles map[string]string
+	var replacements, extensions, renameFiles, auxFiles map[string]string
 	urlFormat = args[0]
 	if *opt.replacements != "" {
-		replacements = parseArgToStrMap(*opt.replacements, "replacement")
+		replacements = parseArgToStrMap(*opt.replacements, "replacement", cmd.logger)
 	}
 	if *opt.extensions != "" {
-		extensions = parseArgToStrMap(*opt.extensions, "extension")
+		extensions = parseArgToStrMap(*opt.extensions, "extension", cmd.logger)
 	}
 	if *opt.renameFiles != "" {
-		renameFiles = parseArgToStrMap(*opt.renameFiles, "rename-files")
+		renameFiles = parseArgToStrMap(*opt.renameFiles, "rename-files", cmd.logger)
+	}
+	if *opt.auxFiles != "" {
+		auxFiles = parseArgToStrMap(*opt.auxFiles, "aux-files", cmd.logger)
+	}
+	strip, err := stripComponentsOpt(cmd.flags, opt.strip)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
 	}
 
 	rev := &item.ItemRevision{
-		URLFormat:    urlFormat,
-		Version:      *opt.version,
-		Replacements: replacements,
-		Extension:    extensions,
-		RenameFiles:  renameFiles,
+		URLFormat:          urlFormat,
+		Version:            *opt.version,
+		ChecksumURLFormat:  *opt.sumURL,
+		SignatureURLFormat: *opt.sigURL,
+		PublicKey:          *opt.publicKey,
+		Replacements:       replacements,
+		Extension:          extensions,
+		RenameFiles:        renameFiles,
+		Files:              parseArgToStrList(*opt.files),
+		AuxFiles:           auxFiles,
+		StripComponents:    strip,
+		Subdir:             *opt.subdir,
 	}
 
 	gen, err := item.GenerateItemJSON(rev, true)
@@ -146,7 +176,7 @@ func (cmd *createCmd) run(args []string) (exit int) {
 	return exitOK
 }
 
-func parseArgToStrMap(arg, kind string) (m map[string]string) {
+func parseArgToStrMap(arg, kind string, logger lv.Standard) (m map[string]string) {
 	m = make(map[string]string)
 	for _, kv := range strings.Split(arg, ",") {
 		params := strings.Split(kv, ":")
@@ -154,8 +184,30 @@ func parseArgToStrMap(arg, kind string) (m map[string]string) {
 		case 2:
 			m[params[0]] = params[1]
 		default:
-			lv.Warnf("Wrong argement for %s: %s", kind, kv)
+			logger.Warnf("Wrong argement for %s: %s", kind, kv)
 		}
 	}
 	return m
 }
+
+// parseArgToStrList splits comma separated arg. It returns nil for empty arg
+func parseArgToStrList(arg string) (list []string) {
+	for _, v := range strings.Split(arg, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// stripComponentsOpt returns value of "--strip-components" option in fs. It returns nil when the
+// option is not specified; so that explicit 0 can be told from default
+func stripComponentsOpt(fs *pflag.FlagSet, strip *int) (n *int, err error) {
+	if !fs.Changed("strip-components") {
+		return nil, nil
+	}
+	if *strip < 0 {
+		return nil, fmt.Errorf("--strip-components must not be negative. Given: %d", *strip)
+	}
+	return strip, nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type outdatedCmd struct {
+	*commonCmd
+	option *outdatedOpts
+}
+
+type outdatedOpts struct {
+	outfmt *string
+	*httpOpts
+	*commonOpts
+}
+
+func newOutdatedCmd(common *commonCmd) (self *outdatedCmd) {
+	self = &outdatedCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &outdatedOpts{
+		outfmt:     fs.StringP("output", "o", "", "# Output format (text,json)"),
+		httpOpts:   newHTTPOpts(fs),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *outdatedCmd) usage() {
+	const help = `Summary:
+  Show installed items which have newer versions on index servers.
+
+Usage:
+  {{.prog}} {{.name}} [NAME...] [-o|--output FORMAT] [GENERAL_OPTIONS]
+
+Each item is compared with the latest version on the index server where it is installed from.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Parse(help))
+	t.Execute(cmd.errs, map[string]string{"prog": cmd.prog, "name": cmd.name})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *outdatedCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	outdated, err := install.FindOutdatedContext(ctx, install.OutdatedOption{
+		Names:        cmd.flags.Args(),
+		Output:       cmd.errs,
+		LogLevel:     cmd.logger.GetLevel(),
+		RegistryPath: registry.DefaultPath(),
+		ConfigPath:   config.DefaultPath(),
+		HTTPOptions:  httpOptions,
+	})
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	switch *opt.outfmt {
+	case outFmtJSON:
+		if outdated == nil {
+			outdated = []install.Outdated{}
+		}
+		b, err := json.MarshalIndent(outdated, "", "  ")
+		if err != nil {
+			fmt.Fprintf(cmd.errs, "Error! Failed to output outdated items. %v\n", err)
+			return exitNG
+		}
+		fmt.Fprintf(cmd.outs, "%s\n", b)
+	case outFmtText, "":
+		fmt.Fprint(cmd.outs, outdatedToText(outdated))
+	default:
+		cmd.logger.Noticef("Unknown output format: %s", *opt.outfmt)
+		fmt.Fprint(cmd.outs, outdatedToText(outdated))
+	}
+
+	return exitOK
+}
+
+func outdatedToText(outdated []install.Outdated) (text string) {
+	format := "%-16s    %-12s    %-12s    %-32s"
+	a := []string{fmt.Sprintf(format, "Name", "Current", "Latest", "Directory")}
+	a = append(a, fmt.Sprint(strings.Repeat("=", 84)))
+	for _, o := range outdated {
+		a = append(a, fmt.Sprintf(format, o.Name, o.Current, o.Latest, o.Dir))
+	}
+	return strings.Join(a, "\n") + "\n"
+}
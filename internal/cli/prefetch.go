@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/binqry/binq"
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/cache"
+	"github.com/binqry/binq/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type prefetchCmd struct {
+	*commonCmd
+	option *prefetchOpts
+}
+
+type prefetchOpts struct {
+	server, lockfile *string
+	*httpOpts
+	*commonOpts
+}
+
+func newPrefetchCmd(common *commonCmd) (self *prefetchCmd) {
+	self = &prefetchCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &prefetchOpts{
+		server:     fs.StringP("server", "s", "", "# Index Server URL"),
+		lockfile:   fs.StringP("lockfile", "l", "", "# Lockfile to resolve locked versions"),
+		httpOpts:   newHTTPOpts(fs),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *prefetchCmd) usage() {
+	const help = `Summary:
+  Download items into caches so that they can be installed in offline mode later.
+
+Usage:
+  <<.prog>> <<.name>> ITEM[@VERSION]... [-s|--server SERVER] [-l|--lockfile LOCKFILE] \
+    [GENERAL_OPTIONS]
+
+Examples:
+  <<.prog>> <<.name>> jq@1.6 peco
+  <<.offline>>=true <<.prog>> install jq@1.6 -d path/to/bin
+
+Downloaded files are cached in <<.cache>>.
+Index and Item JSON are cached in <<.indexCache>>.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Delims("<<", ">>").Parse(help))
+	t.Execute(cmd.errs, map[string]string{
+		"prog": cmd.prog, "name": cmd.name, "offline": binq.EnvKeyOffline,
+		"cache": cache.DefaultDir(), "indexCache": client.DefaultCacheDir(),
+	})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *prefetchCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	if cmd.flags.NArg() == 0 {
+		fmt.Fprintln(cmd.errs, "Error! ITEM is not specified")
+		cmd.usage()
+		return exitNG
+	}
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	var failed []string
+	for _, src := range cmd.flags.Args() {
+		err := install.New(install.RunOption{
+			Source:        src,
+			Output:        cmd.errs,
+			LogLevel:      cmd.logger.GetLevel(),
+			ServerURL:     *opt.server,
+			ConfigPath:    config.DefaultPath(),
+			LockfilePath:  *opt.lockfile,
+			CacheDir:      cache.DefaultDir(),
+			IndexCacheDir: client.DefaultCacheDir(),
+			HTTPOptions:   httpOptions,
+			ProgressFunc:  newProgressFunc(cmd.errs),
+		}).Download(ctx)
+		if err != nil {
+			fmt.Fprintf(cmd.errs, "Error! Failed to prefetch %s. %v\n", src, err)
+			failed = append(failed, src)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		fmt.Fprintf(cmd.outs, "Prefetched %s\n", src)
+	}
+	if len(failed) > 0 {
+		return exitNG
+	}
+
+	return exitOK
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/cache"
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	progressBarWidth    = 30
+	progressLogInterval = 10 * time.Second
+)
+
+// newProgressFunc returns ProgressFunc which draws progress bar on w when w is a terminal.
+// Otherwise, it prints progress lines periodically
+func newProgressFunc(w io.Writer) install.ProgressFunc {
+	if f, ok := w.(*os.File); ok && isatty.IsTerminal(f.Fd()) {
+		return func(p install.Progress) {
+			// Clear the rest of line by "\033[K"
+			fmt.Fprintf(w, "\r%s\033[K", formatProgress(p, true))
+			if p.Done {
+				fmt.Fprintln(w)
+			}
+		}
+	}
+
+	var last time.Time
+	return func(p install.Progress) {
+		if p.Downloaded == p.Resumed {
+			// New download starts
+			last = time.Now()
+			return
+		}
+		if p.Done || time.Since(last) < progressLogInterval {
+			return
+		}
+		last = time.Now()
+		fmt.Fprintf(w, "Downloading %s %s\n", p.URL, formatProgress(p, false))
+	}
+}
+
+// formatProgress formats p like "[=====>    ]  50% 1.0MiB/2.0MiB 512.0KiB/s ETA 2s"
+func formatProgress(p install.Progress, bar bool) (s string) {
+	var b strings.Builder
+	if p.Total > 0 {
+		ratio := float64(p.Downloaded) / float64(p.Total)
+		if ratio > 1 {
+			ratio = 1
+		}
+		if bar {
+			filled := int(ratio * progressBarWidth)
+			b.WriteString("[" + strings.Repeat("=", filled))
+			if filled < progressBarWidth {
+				b.WriteString(">" + strings.Repeat(" ", progressBarWidth-filled-1))
+			}
+			b.WriteString("] ")
+		}
+		fmt.Fprintf(&b, "%3d%% %s/%s", int(ratio*100), cache.FormatSize(p.Downloaded), cache.FormatSize(p.Total))
+	} else {
+		b.WriteString(cache.FormatSize(p.Downloaded))
+	}
+	fmt.Fprintf(&b, " %s/s", cache.FormatSize(int64(p.Rate())))
+	if eta := p.ETA(); eta >= 0 && !p.Done {
+		fmt.Fprintf(&b, " ETA %s", eta.Round(time.Second))
+	}
+	return b.String()
+}
@@ -43,6 +43,7 @@ func newRegisterCmd(common *commonCmd) (self *registerCmd) {
 		path:        fs.StringP("path", "p", "", "# Path for Item in Index"),
 		confirmOpts: newIndiceOpts(fs),
 	}
+	self.signKey = newSignKeyFlag(fs)
 	fs.Usage = self.usage
 	self.flags = fs
 
@@ -55,7 +56,7 @@ func (cmd *registerCmd) usage() {
 
 Usage:
   {{.prog}} {{.name}} pato/to/root[/index.json] path/to/item.json \
-    [-n|--name NAME] [-p|--path PATH] [-y|--yes] [GENERAL_OPTIONS]
+    [-n|--name NAME] [-p|--path PATH] [--sign-key PRIVATE_KEY_FILE] [-y|--yes] [GENERAL_OPTIONS]
 
 Example:
   {{.prog}} {{.name}} index-root-dir foo.json -n foo -p example.com/foo[/index.json]
@@ -71,6 +72,14 @@ When existing name in Index is specified, the JSON file should be replaced with
 If you want to modify name or path in Index without altering its content, use "{{.prog}} modify"
 command.
 
+With "--sign-key" option, detached signatures of Index JSON and Item JSON are written as
+"index.json.sig" and "<Item JSON>.sig". Clients which trust the paired public key reject data
+without valid signature.
+PRIVATE_KEY_FILE contains a PEM encoded PKCS #8 Ed25519 private key, or a base64/hex encoded
+Ed25519 seed.
+Without the option, existing signatures of rewritten files are removed because they no longer
+match.
+
 Options:
 `
 
@@ -96,7 +105,11 @@ func (cmd *registerCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitNG
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
+	if err := cmd.loadSignKey(); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
 	fileIndex, err := resolveIndexPathByArg(args[0])
 	if err != nil {
@@ -122,7 +135,7 @@ func (cmd *registerCmd) run(args []string) (exit int) {
 	}
 	indice := idx.Find(name)
 	if pathItem == "" && indice == nil {
-		pathItem = selectPathForItem(obj, fileItem)
+		pathItem = selectPathForItem(obj, fileItem, cmd.logger)
 	}
 
 	var oldPathItem string
@@ -155,6 +168,10 @@ func (cmd *registerCmd) run(args []string) (exit int) {
 		return exitNG
 	}
 	fmt.Fprintf(cmd.outs, "Copied Item JSON: %s => %s\n", fileItem, destPathItem)
+	if err = cmd.signRewrittenFile(destPathItem); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
 	if oldPathItem != "" {
 		oldPathItem = filepath.Join(filepath.Dir(fileIndex), oldPathItem)
@@ -162,8 +179,9 @@ func (cmd *registerCmd) run(args []string) (exit int) {
 		switch err {
 		case nil:
 			fmt.Fprintf(cmd.outs, "Deleted old Item JSON: %s\n", oldPathItem)
+			removeFile(oldPathItem + ".sig")
 		case errFileNotFound:
-			lv.Warnf("Can't remove file: %s. Not Found", oldPathItem)
+			cmd.logger.Warnf("Can't remove file: %s. Not Found", oldPathItem)
 		default:
 			fmt.Fprintf(cmd.errs, "Error! %v\n", err)
 			return exitNG
@@ -175,17 +193,17 @@ func (cmd *registerCmd) run(args []string) (exit int) {
 
 func (cmd *registerCmd) decodeOrGenerateIndex(file string) (idx *schema.Index, err error) {
 	if _, _err := os.Stat(file); os.IsNotExist(_err) {
-		lv.Noticef("Index file doesn't exist; will be created")
+		cmd.logger.Noticef("Index file doesn't exist; will be created")
 		return schema.NewIndex(), nil
 	}
 
 	return decodeIndex(cmd, file)
 }
 
-func selectPathForItem(obj *item.Item, fileItem string) (pathItem string) {
+func selectPathForItem(obj *item.Item, fileItem string, logger lv.Standard) (pathItem string) {
 	uf, err := url.Parse(obj.Meta.URLFormat)
 	if err != nil {
-		lv.Warnf("Failed to parse url-format of item: %s. %v", obj.Meta.URLFormat, err)
+		logger.Warnf("Failed to parse url-format of item: %s. %v", obj.Meta.URLFormat, err)
 		return fileItem
 	}
 
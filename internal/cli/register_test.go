@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRegisterSignature checks that signatures are removed when files are rewritten without key
+func TestRegisterSignature(t *testing.T) {
+	prog := "binq"
+	tmpdir := t.TempDir()
+	root := filepath.Join(tmpdir, "root")
+	key := filepath.Join(tmpdir, "sign.key")
+	if err := ioutil.WriteFile(key, []byte(strings.Repeat("01", 32)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		itemJSON := `{"meta": {"url-format": "https://example.com/` + name + `"}}`
+		if err := ioutil.WriteFile(filepath.Join(tmpdir, name+".json"), []byte(itemJSON), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexSig := filepath.Join(root, "index.json.sig")
+	fooSig := filepath.Join(root, "foo.json.sig")
+	assertExists := func(t *testing.T, path string, want bool) {
+		t.Helper()
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("Existence of %s mismatch. Want: %v", path, want)
+		}
+	}
+
+	testCases := []testCaseRun{
+		{
+			args: []string{"register", root, filepath.Join(tmpdir, "foo.json"), "-p", "foo.json",
+				"--sign-key", key, "-y"},
+			exit: exitOK, outStr: "Signed " + filepath.Join(root, "index.json"),
+			errStr: "Index file doesn't exist",
+			check: func(t *testing.T) {
+				assertExists(t, indexSig, true)
+				assertExists(t, fooSig, true)
+			},
+		},
+		{
+			// Index is rewritten without key
+			args: []string{"register", root, filepath.Join(tmpdir, "bar.json"), "-p", "bar.json", "-y"},
+			exit: exitOK, outStr: "Saved " + filepath.Join(root, "index.json"),
+			errStr: "Removed " + indexSig + " which no longer matches",
+			check: func(t *testing.T) {
+				assertExists(t, indexSig, false)
+				assertExists(t, fooSig, true)
+			},
+		},
+		{
+			// Only Item JSON is rewritten without key
+			args: []string{"register", root, filepath.Join(tmpdir, "foo.json"), "-p", "foo.json", "-y"},
+			exit: exitOK, outStr: "Copied Item JSON",
+			errStr: "Removed " + fooSig + " which no longer matches",
+			check: func(t *testing.T) {
+				assertExists(t, fooSig, false)
+			},
+		},
+	}
+	for i, tt := range testCases {
+		// Each case depends on the result of the previous one
+		if !t.Run(fmt.Sprintf("%d:%s", i, tt.args[0]), func(t *testing.T) { subtestRun(t, prog, tt) }) {
+			break
+		}
+	}
+}
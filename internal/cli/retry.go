@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/binqry/binq"
+	"github.com/binqry/binq/client/http"
+	"github.com/spf13/pflag"
+)
+
+type retryOpts struct {
+	retry      *int
+	retryDelay *time.Duration
+	flags      *pflag.FlagSet
+}
+
+func newRetryOpts(fs *pflag.FlagSet) *retryOpts {
+	return &retryOpts{
+		retry: fs.Int("retry", 0,
+			fmt.Sprintf("# Max attempts of HTTP request on transient errors. 0 or 1 disables retry (default %d)",
+				http.DefaultRetryPolicy.MaxAttempts)),
+		retryDelay: fs.Duration("retry-delay", 0,
+			fmt.Sprintf("# Initial wait before retry which doubles on each retry (default %s)",
+				http.DefaultRetryPolicy.BaseDelay)),
+		flags: fs,
+	}
+}
+
+// applyRetryOpts sets retry policy of HTTP requests by options and environment variables.
+// Options take precedence over environment variables
+func applyRetryOpts(opt *retryOpts, policy *http.RetryPolicy) error {
+	if v := os.Getenv(binq.EnvKeyRetry); v != "" {
+		n, _err := strconv.Atoi(v)
+		if _err != nil || n < 0 {
+			return fmt.Errorf("Invalid %s: %s", binq.EnvKeyRetry, v)
+		}
+		policy.MaxAttempts = maxAttempts(n)
+	}
+	if v := os.Getenv(binq.EnvKeyRetryDelay); v != "" {
+		d, _err := time.ParseDuration(v)
+		if _err != nil || d <= 0 {
+			return fmt.Errorf("Invalid %s: %s", binq.EnvKeyRetryDelay, v)
+		}
+		policy.BaseDelay = d
+	}
+	if opt.flags.Changed("retry") {
+		if *opt.retry < 0 {
+			return fmt.Errorf("Invalid --retry: %d", *opt.retry)
+		}
+		policy.MaxAttempts = maxAttempts(*opt.retry)
+	}
+	if opt.flags.Changed("retry-delay") {
+		if *opt.retryDelay <= 0 {
+			return fmt.Errorf("Invalid --retry-delay: %s", *opt.retryDelay)
+		}
+		policy.BaseDelay = *opt.retryDelay
+	}
+	return nil
+}
+
+// maxAttempts converts attempts given by user into MaxAttempts of http.RetryPolicy in which zero
+// means the default. Given 0 means no retry as well as 1
+func maxAttempts(n int) (attempts int) {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
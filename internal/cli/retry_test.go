@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/binqry/binq"
+	"github.com/binqry/binq/client/http"
+	"github.com/spf13/pflag"
+)
+
+func TestApplyRetryOpts(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      []string
+		env       string
+		want      int
+		wantError bool
+	}{
+		{name: "default", want: 0},
+		{name: "option", args: []string{"--retry", "5"}, want: 5},
+		{name: "option 0", args: []string{"--retry", "0"}, env: "5", want: 1},
+		{name: "env", env: "2", want: 2},
+		{name: "env 0", env: "0", want: 1},
+		{name: "option over env", args: []string{"--retry", "4"}, env: "2", want: 4},
+		{name: "negative option", args: []string{"--retry", "-1"}, wantError: true},
+		{name: "negative env", env: "-1", wantError: true},
+		{name: "invalid env", env: "x", wantError: true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(binq.EnvKeyRetry, tt.env)
+			t.Setenv(binq.EnvKeyRetryDelay, "")
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			opt := newRetryOpts(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parsing arguments failed. %v", err)
+			}
+			policy := http.RetryPolicy{BaseDelay: time.Second}
+			err := applyRetryOpts(opt, &policy)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error but got nil. Policy: %+v", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error. %v", err)
+			}
+			if policy.MaxAttempts != tt.want {
+				t.Errorf("MaxAttempts mismatch. Want: %d, Got: %d", tt.want, policy.MaxAttempts)
+			}
+		})
+	}
+}
+
+func TestApplyRetryDelay(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      []string
+		env       string
+		want      time.Duration
+		wantError bool
+	}{
+		{name: "default", want: time.Second},
+		{name: "option", args: []string{"--retry-delay", "3s"}, want: 3 * time.Second},
+		{name: "env", env: "500ms", want: 500 * time.Millisecond},
+		{name: "option over env", args: []string{"--retry-delay", "2s"}, env: "5s", want: 2 * time.Second},
+		{name: "zero option", args: []string{"--retry-delay", "0"}, wantError: true},
+		{name: "negative option", args: []string{"--retry-delay", "-1s"}, wantError: true},
+		{name: "zero env", env: "0s", wantError: true},
+		{name: "negative env", env: "-1s", wantError: true},
+		{name: "invalid env", env: "x", wantError: true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(binq.EnvKeyRetry, "")
+			t.Setenv(binq.EnvKeyRetryDelay, tt.env)
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			opt := newRetryOpts(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parsing arguments failed. %v", err)
+			}
+			policy := http.RetryPolicy{BaseDelay: time.Second}
+			err := applyRetryOpts(opt, &policy)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error but got nil. Policy: %+v", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error. %v", err)
+			}
+			if policy.BaseDelay != tt.want {
+				t.Errorf("BaseDelay mismatch. Want: %s, Got: %s", tt.want, policy.BaseDelay)
+			}
+		})
+	}
+}
@@ -2,10 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"runtime"
 	"text/template"
 
 	"github.com/binqry/binq/schema/item"
-	"github.com/progrhyme/go-lv"
 	"github.com/spf13/pflag"
 )
 
@@ -15,8 +15,10 @@ type reviseCmd struct {
 }
 
 type reviseOpts struct {
-	version, urlFormat, replacements, extensions, renameFiles, checksums *string
-	delete, latest, noLatest                                             *bool
+	version, urlFormat, sumURL, sigURL, replacements, extensions, renameFiles, files, auxFiles, subdir, checksums, publicKey *string
+	strip                                                                                                                    *int
+	delete, latest, noLatest, importSums                                                                                     *bool
+	*httpOpts
 	*confirmOpts
 }
 
@@ -35,10 +37,19 @@ func newReviseCmd(common *commonCmd) (self *reviseCmd) {
 		replacements: fs.StringP("replace", "r", "", "# JSON parameter for \"replacements\""),
 		extensions:   fs.StringP("ext", "e", "", "# JSON parameter for \"extensions\""),
 		renameFiles:  fs.StringP("rename", "R", "", "# JSON parameter for \"rename-files\""),
+		files:        fs.String("files", "", "# JSON parameter for \"files\". Comma separated glob patterns"),
+		auxFiles:     fs.String("aux-files", "", "# JSON parameter for \"aux-files\""),
+		strip:        fs.Int("strip-components", 0, "# JSON parameter for \"strip-components\""),
+		subdir:       fs.String("subdir", "", "# JSON parameter for \"subdir\""),
 		checksums:    fs.StringP("sum", "s", "", "# JSON parameter for \"checksums\""),
+		sumURL:       fs.StringP("sum-url", "c", "", "# JSON parameter for \"checksum-url-format\""),
+		sigURL:       fs.String("sig-url", "", "# JSON parameter for \"signature-url-format\""),
+		publicKey:    fs.String("public-key", "", "# JSON parameter for \"public-key\""),
+		importSums:   fs.Bool("import-sums", false, "# Import checksums from checksum file of upstream"),
 		delete:       fs.Bool("delete", false, "# Delete version"),
 		latest:       fs.Bool("latest", false, "# Add or Update as Latest Version"),
 		noLatest:     fs.Bool("no-latest", false, "# Add or Update as Not Latest Version"),
+		httpOpts:     newHTTPOpts(fs),
 		confirmOpts: &confirmOpts{
 			yes:        fs.BoolP("yes", "y", false, "# Update JSON file without confirmation"),
 			commonOpts: newCommonOpts(fs),
@@ -58,7 +69,11 @@ Usage:
   # Add or Update Version
   <<.prog>> <<.name>> path/to/item.json [-v|--version] VERSION \
     [-s|--sum CHECKSUMS] [-u|--url URL_FORMAT] [-r|--replace REPLACEMENTS] [-e|--ext EXTENSIONS] \
-    [-R|--rename RENAME_FILES] [--latest] [--no-latest] [-y|--yes] [GENERAL_OPTIONS]
+    [-R|--rename RENAME_FILES] [--files FILES] [--aux-files AUX_FILES] \
+    [--strip-components N] [--subdir SUBDIR] \
+    [-c|--sum-url CHECKSUM_URL_FORMAT] [--import-sums] \
+    [--sig-url SIGNATURE_URL_FORMAT] [--public-key PUBLIC_KEY] \
+    [--latest] [--no-latest] [-y|--yes] [GENERAL_OPTIONS]
 
   # Delete Version
   <<.prog>> <<.name>> path/to/item.json VERSION --delete [-y|--yes] [GENERAL_OPTIONS]
@@ -74,17 +89,31 @@ Examples:
   <<.prog>> <<.name>> foo.json 0.2.0 \
     -s "foo-win.zip:${sha256_win},foo-mac.zip:${sha256_mac}" --latest
 
+  # Add v0.3.0 importing checksums of all files from "SHA256SUMS" published by upstream
+  <<.prog>> <<.name>> foo.json 0.3.0 --import-sums \
+    -c "https://github.com/foo/foo/releases/download/v{{.Version}}/SHA256SUMS"
+
 Parameters:
 - CHECKSUMS
 
   Format: "<File1>:<Checksum1>[:<Algorithm1>],..."
 
-  SHA-256 is the default algorithm. To use CRC or MD5, specify suffix ":crc" or ":md5" like this:
+  SHA-256 is the default algorithm. To use other algorithm, specify its name as suffix like this:
   '-s "foo.zip:5993c24b:crc"'.
-  Other algorithm is not supported for now.
+  Supported algorithms: sha256, sha512, blake2b-256, blake2b-512, crc, md5
+
+  Checksums of two or more algorithms can be specified for the same file like this:
+  '-s "foo.zip:${sha256}:sha256,foo.zip:${sha512}:sha512"'.
+
+- CHECKSUM_URL_FORMAT
 
-Limitation:
-  It is not expected to specify two or more types of checksums per file.
+  URL format of checksum file like "SHA256SUMS", "checksums.txt" or "foo.zip.sha256".
+  Both GNU coreutils style and BSD style (with "--tag" option) are supported.
+  It can also be defined in "meta" of Item Manifest.
+
+  With "--import-sums" option, checksums of all files listed in the checksum file are added.
+  When checksum is not embedded in Item Manifest, "binq install" consults the checksum file.
+  The checksum file is downloaded with the same HTTP options and settings as "binq install".
 
 Options:
 `
@@ -111,7 +140,7 @@ func (cmd *reviseCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitNG
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
 
 	file := args[0]
 	var version string
@@ -125,26 +154,29 @@ func (cmd *reviseCmd) run(args []string) (exit int) {
 		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
 		return exitNG
 	}
-	lv.Debugf("Decoded JSON: %s", obj)
+	cmd.logger.Debugf("Decoded JSON: %s", obj)
 
 	if *opt.delete {
 		if deleted := obj.DeleteRevision(version); deleted == false {
 			fmt.Fprintf(cmd.errs, "Error! Version does not exist: %s\n", version)
 			return exitNG
 		}
-		lv.Debugf("Version %s deleted. After Item: %s", version, obj)
+		cmd.logger.Debugf("Version %s deleted. After Item: %s", version, obj)
 		return updateItemJSON(cmd, obj, file, orig)
 	}
 
-	var replacements, extensions, renameFiles map[string]string
+	var replacements, extensions, renameFiles, auxFiles map[string]string
 	if *opt.replacements != "" {
-		replacements = parseArgToStrMap(*opt.replacements, "replacement")
+		replacements = parseArgToStrMap(*opt.replacements, "replacement", cmd.logger)
 	}
 	if *opt.extensions != "" {
-		extensions = parseArgToStrMap(*opt.extensions, "extension")
+		extensions = parseArgToStrMap(*opt.extensions, "extension", cmd.logger)
 	}
 	if *opt.renameFiles != "" {
-		renameFiles = parseArgToStrMap(*opt.renameFiles, "rename-files")
+		renameFiles = parseArgToStrMap(*opt.renameFiles, "rename-files", cmd.logger)
+	}
+	if *opt.auxFiles != "" {
+		auxFiles = parseArgToStrMap(*opt.auxFiles, "aux-files", cmd.logger)
 	}
 
 	mode := item.ReviseModeNatural
@@ -154,17 +186,55 @@ func (cmd *reviseCmd) run(args []string) (exit int) {
 		mode = item.ReviseModeOld
 	}
 
+	sums, err := item.NewItemChecksums(*opt.checksums)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+	strip, err := stripComponentsOpt(cmd.flags, opt.strip)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 	rev := &item.ItemRevision{
-		Version:      version,
-		Checksums:    item.NewItemChecksums(*opt.checksums),
-		URLFormat:    *opt.urlFormat,
-		Replacements: replacements,
-		Extension:    extensions,
-		RenameFiles:  renameFiles,
+		Version:            version,
+		Checksums:          sums,
+		URLFormat:          *opt.urlFormat,
+		ChecksumURLFormat:  *opt.sumURL,
+		SignatureURLFormat: *opt.sigURL,
+		PublicKey:          *opt.publicKey,
+		Replacements:       replacements,
+		Extension:          extensions,
+		RenameFiles:        renameFiles,
+		Files:              parseArgToStrList(*opt.files),
+		AuxFiles:           auxFiles,
+		StripComponents:    strip,
+		Subdir:             *opt.subdir,
+	}
+
+	if err = obj.AddOrUpdateRevision(rev, mode, cmd.logger); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
 	}
+	cmd.logger.Debugf("Version %s updated. After Item: %s", version, obj)
 
-	obj.AddOrUpdateRevision(rev, mode)
-	lv.Debugf("Version %s updated. After Item: %s", version, obj)
+	if *opt.importSums {
+		httpOptions, err := applyHTTPOpts(opt.httpOpts)
+		if err != nil {
+			fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+			return exitNG
+		}
+		ctx, stop := signalContext()
+		defer stop()
+		param := item.FormatParam{OS: runtime.GOOS, Arch: runtime.GOARCH}
+		if _, err = importChecksums(
+			ctx, obj, obj.GetRevision(version), param, httpOptions, cmd.logger,
+		); err != nil {
+			fmt.Fprintf(cmd.errs, "Error! Failed to import checksums. %v\n", err)
+			return exitNG
+		}
+		cmd.logger.Debugf("Checksums imported. After Item: %s", obj)
+	}
 
 	return updateItemJSON(cmd, obj, file, orig)
 }
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/registry"
+	"github.com/spf13/pflag"
+)
+
+type rollbackCmd struct {
+	*commonCmd
+	option *rollbackOpts
+}
+
+type rollbackOpts struct {
+	directory *string
+	*commonOpts
+}
+
+func newRollbackCmd(common *commonCmd) (self *rollbackCmd) {
+	self = &rollbackCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &rollbackOpts{
+		directory:  fs.StringP("directory", "d", "", "# Directory where the item is installed"),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *rollbackCmd) usage() {
+	const help = `Summary:
+  Go back to the previously active version of an item installed with "--versioned".
+
+Usage:
+  {{.prog}} {{.name}} NAME [-d|--dir DIRECTORY] [GENERAL_OPTIONS]
+
+Symbolic links in DIRECTORY are switched to the version which was active before
+the current one. Running it again switches them back.
+DIRECTORY can be omitted when the item is installed in only one directory.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Parse(help))
+	t.Execute(cmd.errs, map[string]string{"prog": cmd.prog, "name": cmd.name})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *rollbackCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	if cmd.flags.NArg() == 0 {
+		fmt.Fprintln(cmd.errs, "Error! NAME is not specified")
+		cmd.usage()
+		return exitNG
+	}
+	cmd.setLogLevelByOption(opt)
+
+	name := cmd.flags.Arg(0)
+	entry, err := install.Rollback(install.UseOption{
+		Name:         name,
+		DestDir:      *opt.directory,
+		Logger:       cmd.logger,
+		RegistryPath: registry.DefaultPath(),
+	})
+	if err != nil {
+		if errors.Is(err, install.ErrNoPreviousVersion) {
+			fmt.Fprintf(cmd.errs, "Error! No previous version of %s is known\n", name)
+			return exitNG
+		}
+		fmt.Fprintf(cmd.errs, "Error! %s\n", switchErrorMessage(name, err))
+		return exitNG
+	}
+
+	fmt.Fprintf(cmd.outs, "Rolled back %s to %s in %s\n", entry.Name, entry.Version, entry.Dir)
+	return exitOK
+}
@@ -12,7 +12,6 @@ import (
 
 	"github.com/binqry/binq"
 	"github.com/binqry/binq/install"
-	"github.com/progrhyme/go-lv"
 	"github.com/spf13/pflag"
 )
 
@@ -75,7 +74,12 @@ func (cmd *selfUpgradeCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitOK
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
 	ident := "binq"
 	if *opt.identifier != "" {
@@ -94,18 +98,21 @@ func (cmd *selfUpgradeCmd) run(args []string) (exit int) {
 	fmt.Fprintf(cmd.errs, "Check and fetch latest %s ...\n", ident)
 	logDest := &strings.Builder{}
 	opts := install.RunOption{
-		Source:    ident,
-		DestDir:   tmpdir,
-		Output:    logDest,
-		LogLevel:  lv.GetLevel(),
-		ServerURL: *opt.server,
-		NewerThan: binq.Version,
+		Source:      ident,
+		DestDir:     tmpdir,
+		Output:      logDest,
+		LogLevel:    cmd.logger.GetLevel(),
+		ServerURL:   *opt.server,
+		NewerThan:   binq.Version,
+		HTTPOptions: httpOptions,
 	}
-	err = install.Run(opts)
+	ctx, stop := signalContext()
+	defer stop()
+	err = install.New(opts).Run(ctx)
 	switch {
 	case err == nil:
 		// OK
-	case errors.As(err, &install.ErrVersionNotNewerThanThreshold):
+	case errors.Is(err, install.ErrVersionNotNewerThanThreshold):
 		fmt.Fprintf(cmd.errs, "No need to upgrade\n")
 		return exitOK
 	default:
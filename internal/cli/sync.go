@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/binqry/binq"
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/cache"
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/config"
+	"github.com/binqry/binq/schema/project"
+	"github.com/spf13/pflag"
+)
+
+type syncCmd struct {
+	*commonCmd
+	option *syncOpts
+}
+
+type syncOpts struct {
+	file            *string
+	noLock, offline *bool
+	*httpOpts
+	*commonOpts
+}
+
+func newSyncCmd(common *commonCmd) (self *syncCmd) {
+	self = &syncCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &syncOpts{
+		file:       fs.StringP("file", "f", "", "# Path to Toolfile"),
+		noLock:     fs.Bool("no-lock", false, "# Don't use and update Lockfile"),
+		offline:    fs.Bool("offline", false, "# Install items only from caches without network access"),
+		httpOpts:   newHTTPOpts(fs),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *syncCmd) usage() {
+	const help = `Summary:
+  Install items declared in project Toolfile; and uninstall ones no longer declared.
+
+Usage:
+  <<.prog>> <<.name>> [-f|--file TOOLFILE] [--no-lock] [--offline] [GENERAL_OPTIONS]
+
+When TOOLFILE is not specified, "binq.json" or "Binqfile" in current directory is used.
+
+Resolved URLs and checksums are pinned in "binq.lock" next to TOOLFILE.
+Later runs install items from the Lockfile; and fail when downloaded content differs from it.
+
+Toolfile Example:
+  {
+    "server": "https://binqry.github.io/index/",
+    "dir": "bin",
+    "items": [
+      "jq@1.6",
+      "peco",
+      {"name": "kustomize", "version": "3.8.0", "file": "kustomize"}
+    ]
+  }
+
+Each item can have "server" and "dir" properties to override the top-level ones.
+Relative "dir" is resolved from the directory of Toolfile; and defaults to "bin".
+
+In offline mode, which is also enabled by environment variable <<.offline>>=true, items are
+installed only from caches. Run "<<.prog>> prefetch" beforehand to populate the caches.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Delims("<<", ">>").Parse(help))
+	t.Execute(cmd.errs, map[string]string{
+		"prog": cmd.prog, "name": cmd.name, "offline": binq.EnvKeyOffline,
+	})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *syncCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	file := *opt.file
+	if file == "" {
+		var err error
+		if file, err = project.FindToolfile("."); err != nil {
+			fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+			return exitNG
+		}
+	}
+
+	var lockfile string
+	if !*opt.noLock {
+		lockfile = project.LockfilePathFor(file)
+	}
+	ctx, stop := signalContext()
+	defer stop()
+	result, err := install.SyncContext(ctx, install.SyncOption{
+		Toolfile:      file,
+		Lockfile:      lockfile,
+		Output:        cmd.errs,
+		LogLevel:      cmd.logger.GetLevel(),
+		RegistryPath:  registry.DefaultPath(),
+		ConfigPath:    config.DefaultPath(),
+		HTTPOptions:   httpOptions,
+		ProgressFunc:  newProgressFunc(cmd.errs),
+		CacheDir:      cache.DefaultDir(),
+		IndexCacheDir: client.DefaultCacheDir(),
+		Offline:       isOffline(*opt.offline),
+	})
+	if result != nil {
+		for _, s := range result.Installed {
+			fmt.Fprintf(cmd.outs, "Installed %s\n", s)
+		}
+		for _, s := range result.Removed {
+			fmt.Fprintf(cmd.outs, "Removed %s\n", s)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	return exitOK
+}
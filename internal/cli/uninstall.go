@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/registry"
+	"github.com/spf13/pflag"
+)
+
+type uninstallCmd struct {
+	*commonCmd
+	option *uninstallOpts
+}
+
+type uninstallOpts struct {
+	directory *string
+	*commonOpts
+}
+
+func newUninstallCmd(common *commonCmd) (self *uninstallCmd) {
+	self = &uninstallCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &uninstallOpts{
+		directory:  fs.StringP("directory", "d", "", "# Uninstall only from this directory"),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *uninstallCmd) usage() {
+	const help = `Summary:
+  Uninstall an item installed by {{.prog}}.
+
+Usage:
+  {{.prog}} {{.name}} NAME [-d|--dir DIRECTORY] [GENERAL_OPTIONS]
+
+Files recorded on installation are removed.
+When DIRECTORY is not specified, the item is uninstalled from all directories.
+
+Run "{{.prog}} list" to see installed items.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Parse(help))
+	t.Execute(cmd.errs, map[string]string{"prog": cmd.prog, "name": cmd.name})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *uninstallCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	if cmd.flags.NArg() == 0 {
+		fmt.Fprintln(cmd.errs, "Error! NAME is not specified")
+		cmd.usage()
+		return exitNG
+	}
+	cmd.setLogLevelByOption(opt)
+
+	name := cmd.flags.Arg(0)
+	_, err := install.Uninstall(install.UninstallOption{
+		Name:         name,
+		DestDir:      *opt.directory,
+		Output:       cmd.errs,
+		LogLevel:     cmd.logger.GetLevel(),
+		RegistryPath: registry.DefaultPath(),
+	})
+	switch {
+	case err == nil:
+		// OK
+	case errors.Is(err, install.ErrNotInstalled):
+		fmt.Fprintf(cmd.errs, "Error! %s is not installed\n", name)
+		return exitNG
+	default:
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	fmt.Fprintf(cmd.outs, "Uninstalled %s\n", name)
+	return exitOK
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/binqry/binq/client"
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/cache"
+	"github.com/binqry/binq/install/registry"
+	"github.com/binqry/binq/internal/config"
+	"github.com/spf13/pflag"
+)
+
+type upgradeCmd struct {
+	*commonCmd
+	option *upgradeOpts
+}
+
+type upgradeOpts struct {
+	*httpOpts
+	*commonOpts
+}
+
+func newUpgradeCmd(common *commonCmd) (self *upgradeCmd) {
+	self = &upgradeCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &upgradeOpts{
+		httpOpts:   newHTTPOpts(fs),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *upgradeCmd) usage() {
+	const help = `Summary:
+  Upgrade installed items to the latest versions.
+
+Usage:
+  {{.prog}} {{.name}} [NAME...] [GENERAL_OPTIONS]
+
+When NAME is omitted, all outdated items are upgraded.
+Newer versions are installed into the same directories where current versions are installed.
+
+Run "{{.prog}} outdated" to see items to be upgraded.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Parse(help))
+	t.Execute(cmd.errs, map[string]string{"prog": cmd.prog, "name": cmd.name})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *upgradeCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	upgraded, err := install.UpgradeContext(ctx, install.UpgradeOption{
+		Names:         cmd.flags.Args(),
+		Output:        cmd.errs,
+		LogLevel:      cmd.logger.GetLevel(),
+		RegistryPath:  registry.DefaultPath(),
+		ConfigPath:    config.DefaultPath(),
+		HTTPOptions:   httpOptions,
+		ProgressFunc:  newProgressFunc(cmd.errs),
+		CacheDir:      cache.DefaultDir(),
+		IndexCacheDir: client.DefaultCacheDir(),
+	})
+	for _, o := range upgraded {
+		fmt.Fprintf(cmd.outs, "Upgraded %s\n", o)
+	}
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+	if len(upgraded) == 0 {
+		fmt.Fprintln(cmd.errs, "No need to upgrade")
+	}
+
+	return exitOK
+}
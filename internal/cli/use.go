@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/binqry/binq/install"
+	"github.com/binqry/binq/install/registry"
+	"github.com/spf13/pflag"
+)
+
+type useCmd struct {
+	*commonCmd
+	option *useOpts
+}
+
+type useOpts struct {
+	directory *string
+	*commonOpts
+}
+
+func newUseCmd(common *commonCmd) (self *useCmd) {
+	self = &useCmd{commonCmd: common}
+
+	fs := pflag.NewFlagSet(self.name, pflag.ContinueOnError)
+	fs.SetOutput(self.errs)
+	self.option = &useOpts{
+		directory:  fs.StringP("directory", "d", "", "# Directory where the item is installed"),
+		commonOpts: newCommonOpts(fs),
+	}
+	fs.Usage = self.usage
+	self.flags = fs
+
+	return self
+}
+
+func (cmd *useCmd) usage() {
+	const help = `Summary:
+  Switch the active version of an item installed with "--versioned".
+
+Usage:
+  {{.prog}} {{.name}} NAME@VERSION [-d|--dir DIRECTORY] [GENERAL_OPTIONS]
+
+Symbolic links in DIRECTORY are switched to VERSION which has been installed by
+"{{.prog}} install --versioned". Nothing is downloaded.
+DIRECTORY can be omitted when the item is installed in only one directory.
+
+Run "{{.prog}} rollback NAME" to go back to the previously active version.
+
+Options:
+`
+
+	t := template.Must(template.New("usage").Parse(help))
+	t.Execute(cmd.errs, map[string]string{"prog": cmd.prog, "name": cmd.name})
+	cmd.flags.PrintDefaults()
+}
+
+func (cmd *useCmd) run(args []string) (exit int) {
+	if err := cmd.flags.Parse(args); err != nil {
+		fmt.Fprintf(cmd.errs, "Error! Parsing arguments failed. %s\n", err)
+		return exitNG
+	}
+
+	opt := cmd.option
+	if *opt.help {
+		cmd.usage()
+		return exitOK
+	}
+	if cmd.flags.NArg() == 0 {
+		fmt.Fprintln(cmd.errs, "Error! NAME@VERSION is not specified")
+		cmd.usage()
+		return exitNG
+	}
+	terms := strings.SplitN(cmd.flags.Arg(0), "@", 2)
+	if len(terms) < 2 || terms[0] == "" || terms[1] == "" {
+		fmt.Fprintf(cmd.errs, "Error! Invalid argument: %s. NAME@VERSION is required\n", cmd.flags.Arg(0))
+		return exitNG
+	}
+	cmd.setLogLevelByOption(opt)
+
+	entry, err := install.Use(install.UseOption{
+		Name:         terms[0],
+		Version:      terms[1],
+		DestDir:      *opt.directory,
+		Logger:       cmd.logger,
+		RegistryPath: registry.DefaultPath(),
+	})
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %s\n", switchErrorMessage(terms[0], err))
+		return exitNG
+	}
+
+	fmt.Fprintf(cmd.outs, "Using %s@%s in %s\n", entry.Name, entry.Version, entry.Dir)
+	return exitOK
+}
+
+// switchErrorMessage returns error message of use and rollback commands
+func switchErrorMessage(name string, err error) (msg string) {
+	switch {
+	case errors.Is(err, install.ErrNotInstalled):
+		return fmt.Sprintf("%s is not installed", name)
+	case errors.Is(err, install.ErrNotVersioned):
+		return fmt.Sprintf("%s is not installed with --versioned", name)
+	}
+	return err.Error()
+}
@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"encoding/hex"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -13,14 +13,13 @@ import (
 	"text/template"
 
 	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/install/cache"
 	"github.com/binqry/binq/internal/erron"
 	"github.com/binqry/binq/schema/item"
 	"github.com/progrhyme/go-lv"
 	"github.com/spf13/pflag"
 )
 
-const initialDummyChecksum = "--dummy--"
-
 type verifyCmd struct {
 	*confirmCmd
 	option *verifyOpts
@@ -28,7 +27,10 @@ type verifyCmd struct {
 
 type verifyOpts struct {
 	version, os, arch *string
-	keep              *bool
+	keep, fill        *bool
+	importSums        *bool
+	algo              *[]string
+	*httpOpts
 	*confirmOpts
 }
 
@@ -45,7 +47,12 @@ func newVerifyCmd(common *commonCmd) (self *verifyCmd) {
 		version: fs.StringP("version", "v", "", "# JSON parameter for \"version\""),
 		os:      fs.String("os", "", "# JSON parameter for \"{{.OS}}\""),
 		arch:    fs.StringP("arch", "a", "", "# JSON parameter for \"{{.Arch}}\""),
-		keep:    fs.Bool("keep", false, "# Delete version"),
+		keep:    fs.Bool("keep", false, "# Keep downloaded file"),
+		fill:    fs.Bool("fill", false, "# Add checksums of all supported algorithms"),
+		algo:    fs.StringSlice("algo", nil, "# Algorithms of checksums to add. e.g. sha512,blake2b-256"),
+		importSums: fs.Bool(
+			"import-sums", false, "# Import checksums from checksum file of upstream before verification"),
+		httpOpts: newHTTPOpts(fs),
 		confirmOpts: &confirmOpts{
 			yes:        fs.BoolP("yes", "y", false, "# Update JSON file without confirmation"),
 			commonOpts: newCommonOpts(fs),
@@ -64,10 +71,22 @@ func (cmd *verifyCmd) usage() {
 
 Usage:
   <<.prog>> <<.name>> path/to/item.json [-v|--version VERSION] [--os OS] [-a|--arch ARCH] \
-    [-y|--yes] [--keep] [GENERAL_OPTIONS]
+    [-y|--yes] [--keep] [--fill|--algo ALGORITHMS] [--import-sums] [GENERAL_OPTIONS]
 
 When VERSION argument is omitted, the latest version will be verified.
 
+All checksums declared for the file are verified. When no checksum is declared, SHA-256 checksum
+is added. With "--algo" option, checksums of specified algorithms are added. With "--fill" option,
+checksums of all supported algorithms are added.
+
+Supported algorithms: sha256, sha512, blake2b-256, blake2b-512, crc, md5
+
+With "--import-sums" option, checksums of all files listed in the checksum file on
+"checksum-url-format" are imported into the version before verification.
+
+File is downloaded via <<.cache>>. When download is interrupted, it is resumed on next run if
+the server supports Range requests.
+
 Parameters:
 - OS ... windows, darwin, linux etc.
 - ARCH ... 386, amd64, arm etc.
@@ -78,7 +97,9 @@ Options:
 `
 
 	t := template.Must(template.New("usage").Delims("<<", ">>").Parse(help))
-	t.Execute(cmd.errs, map[string]string{"prog": cmd.prog, "name": cmd.name})
+	t.Execute(cmd.errs, map[string]string{
+		"prog": cmd.prog, "name": cmd.name, "cache": cache.DefaultDir(),
+	})
 
 	cmd.flags.PrintDefaults()
 }
@@ -99,7 +120,18 @@ func (cmd *verifyCmd) run(args []string) (exit int) {
 		cmd.usage()
 		return exitNG
 	}
-	setLogLevelByOption(opt)
+	cmd.setLogLevelByOption(opt)
+	httpOptions, err := applyHTTPOpts(opt.httpOpts)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
+
+	algos, err := parseChecksumTypes(*opt.algo)
+	if err != nil {
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
+		return exitNG
+	}
 
 	fileItem := args[0]
 	orig, obj, err := readAndDecodeItemJSONFile(fileItem)
@@ -107,7 +139,7 @@ func (cmd *verifyCmd) run(args []string) (exit int) {
 		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
 		return exitNG
 	}
-	lv.Debugf("Decoded JSON: %s", obj)
+	cmd.logger.Debugf("Decoded JSON: %s", obj)
 
 	rev, err := getItemRevisionByOpt(obj, opt)
 	if err != nil {
@@ -115,6 +147,19 @@ func (cmd *verifyCmd) run(args []string) (exit int) {
 		return exitNG
 	}
 
+	ctx, stop := signalContext()
+	defer stop()
+
+	var imported bool
+	if *opt.importSums {
+		imported, err = importChecksums(ctx, obj, rev, buildURLParamToVerify(opt), httpOptions, cmd.logger)
+		if err != nil {
+			fmt.Fprintf(cmd.errs, "Error! Failed to import checksums. %v\n", err)
+			return exitNG
+		}
+		rev = obj.GetRevision(rev.Version)
+	}
+
 	urlStr, err := rev.GetURL(buildURLParamToVerify(opt))
 	if err != nil {
 		fmt.Fprintf(cmd.errs, "Error! URL build failed. %v\n", err)
@@ -126,16 +171,15 @@ func (cmd *verifyCmd) run(args []string) (exit int) {
 	}
 	fmt.Fprintf(cmd.outs, "GET %s\n", urlStr)
 
-	res, err := http.Fetch(urlStr)
+	content, partial, err := downloadResumable(ctx, urlStr, httpOptions, cmd.logger)
 	if err != nil {
-		fmt.Fprintf(cmd.errs, "Error! Failed to execute HTTP request. %v\n", err)
-		return exitNG
-	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		fmt.Fprintf(cmd.errs, "Error! HTTP response is not OK. Code: %d\n", res.StatusCode)
+		fmt.Fprintf(cmd.errs, "Error! %v\n", err)
 		return exitNG
 	}
+	defer func() {
+		content.Close()
+		partial.Remove()
+	}()
 
 	tmpdir, err := ioutil.TempDir(os.TempDir(), "binq-verify.*")
 	if err != nil {
@@ -166,22 +210,19 @@ func (cmd *verifyCmd) run(args []string) (exit int) {
 
 	cs := rev.GetChecksum(file)
 	if cs == nil {
-		lv.Noticef("Checksum is not provided")
-		cs = &item.ItemChecksum{
-			File:   file,
-			SHA256: initialDummyChecksum, // To be replaced on verification
-		}
+		cmd.logger.Noticef("Checksum is not provided")
+		cs = &item.ItemChecksum{File: file}
 	}
 
-	updated, err := cmd.downloadAndVerify(cs, res.Body, dlFile, dlPath)
+	updated, err := cmd.downloadAndVerify(cs, algos, content, dlFile, dlPath)
 	if err != nil {
 		fmt.Fprintf(cmd.errs, "Error! Failed to verify: %v", err)
 		return exitNG
 	}
 
-	if updated {
+	if updated || imported {
 		obj.UpdateRevisionChecksum(rev.Version, cs)
-		lv.Debugf("Item updated. After Item: %s", obj)
+		cmd.logger.Debugf("Item updated. After Item: %s", obj)
 		return updateItemJSON(cmd, obj, fileItem, orig)
 	}
 
@@ -208,6 +249,17 @@ func getItemRevisionByOpt(obj *item.Item, opt *verifyOpts) (rev *item.ItemRevisi
 	return rev, nil
 }
 
+func parseChecksumTypes(names []string) (types []item.ChecksumType, err error) {
+	for _, name := range names {
+		t := item.ParseChecksumType(name)
+		if t == item.ChecksumTypeUnknown {
+			return nil, fmt.Errorf("Unsupported algorithm: %s", name)
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
 func buildURLParamToVerify(opt *verifyOpts) (param item.FormatParam) {
 	if *opt.os != "" {
 		param.OS = *opt.os
@@ -222,27 +274,95 @@ func buildURLParamToVerify(opt *verifyOpts) (param item.FormatParam) {
 	return param
 }
 
+// downloadAndVerify saves content into destFile verifying all checksums declared in cs.
+// Checksums of algos are computed in addition.
+// Mismatched or missing checksums are set into cs; and different is set true in that case.
 func (cmd *verifyCmd) downloadAndVerify(
-	cs *item.ItemChecksum, content io.ReadCloser, destFile *os.File, destPath string,
+	cs *item.ItemChecksum, algos []item.ChecksumType, content io.ReadCloser, destFile *os.File,
+	destPath string,
 ) (different bool, err error) {
-	sum, hasher, kind := cs.GetSumAndHasher()
-	tee := io.TeeReader(content, hasher)
+	digests := cs.GetDigests()
+	if *cmd.option.fill {
+		algos = item.ChecksumTypes
+	}
+	for _, t := range algos {
+		if cs.GetSum(t) != "" {
+			continue
+		}
+		dup := false
+		for _, d := range digests {
+			if d.Type == t {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			digests = append(digests, item.NewDigest(t, ""))
+		}
+	}
+	if len(digests) == 0 {
+		digests = append(digests, item.NewDigest(item.ChecksumTypeSHA256, ""))
+	}
+
+	tee := io.TeeReader(content, item.DigestWriter(digests))
 	_, _err := io.Copy(destFile, tee)
 	if _err != nil {
 		return different, erron.Errorwf(_err, "Failed to read HTTP response")
 	}
-	lv.Debugf("Saved file %s", destPath)
+	cmd.logger.Debugf("Saved file %s", destPath)
 
-	cksum := hex.EncodeToString(hasher.Sum(nil))
-	lv.Debugf("Sum: %s", cksum)
-	if sum != cksum {
-		if sum != initialDummyChecksum {
-			fmt.Fprintf(cmd.errs, "Warning! Checksum differs. Expected: %s, Got: %s\n", sum, cksum)
+	for _, d := range digests {
+		cmd.logger.Debugf("Sum(%s): %s", d.Type, d.Sum())
+		if d.Match() {
+			continue
 		}
-		cs.SetSum(cksum, kind)
+		if d.Expected != "" {
+			fmt.Fprintf(cmd.errs, "Warning! Checksum differs. Algorithm: %s, Expected: %s, Got: %s\n",
+				d.Type, d.Expected, d.Sum())
+		} else {
+			cmd.logger.Infof("Add checksum. Algorithm: %s", d.Type)
+		}
+		if err = cs.SetSum(d.Sum(), d.Type); err != nil {
+			return different, err
+		}
+		different = true
+	}
+	if different {
 		return true, nil
 	}
 
 	fmt.Fprintf(cmd.outs, "Checksum is OK\n")
 	return false, nil
 }
+
+// downloadResumable downloads content of addr into a partial file in cache directory.
+// Interrupted download is resumed on next run. The partial file should be removed after use
+func downloadResumable(
+	ctx context.Context, addr string, opts http.Options, logger lv.Granular,
+) (content *os.File, partial *cache.Partial, err error) {
+	hc, err := http.NewClient(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := cache.Open(cache.DefaultDir())
+	if err != nil {
+		return nil, nil, err
+	}
+	if partial, err = c.Partial(addr); err != nil {
+		return nil, nil, err
+	}
+	resumed, err := partial.Fetch(ctx, hc, nil)
+	if resumed > 0 {
+		logger.Infof("Resumed download from %d bytes", resumed)
+	}
+	if err != nil {
+		if partial.Size() > 0 {
+			logger.Noticef("Download is interrupted. Run again to resume it")
+		}
+		return nil, nil, err
+	}
+	if content, err = partial.Open(); err != nil {
+		return nil, nil, err
+	}
+	return content, partial, nil
+}
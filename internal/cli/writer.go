@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter writes each line with prefix. Writes through prefixWriters sharing mu are
+// serialized so that lines of concurrent tasks are not mixed up
+type prefixWriter struct {
+	w       io.Writer
+	mu      *sync.Mutex
+	prefix  string
+	midline bool
+}
+
+func (p *prefixWriter) Write(b []byte) (n int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if !p.midline {
+			buf.WriteString(p.prefix)
+		}
+		buf.Write(line)
+		p.midline = line[len(line)-1] != '\n'
+	}
+	if _, err = p.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
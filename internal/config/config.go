@@ -0,0 +1,140 @@
+// Package config handles user configuration file of binq.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/binqry/binq/client/http"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/internal/xdg"
+)
+
+// Config wraps configProps which corresponds to JSON structure of configuration file
+type Config struct {
+	*configProps
+	path string
+}
+
+type configProps struct {
+	// Settings for each index server keyed by server URL
+	Servers map[string]Server `json:"servers,omitempty"`
+	// Settings for HTTP requests
+	HTTP HTTP `json:"http,omitempty"`
+	// Directories to install auxiliary files keyed by role like "man" and "bash-completion"
+	Dirs map[string]string `json:"dirs,omitempty"`
+}
+
+// Server holds settings for an index server
+type Server struct {
+	// Trusted public key to verify signatures of Index and Item JSON on the server
+	PublicKey string `json:"public-key,omitempty"`
+}
+
+// HTTP holds settings for HTTP requests. Timeouts are written in the form like "30s" or "2m"
+type HTTP struct {
+	DialTimeout           string `json:"dial-timeout,omitempty"`
+	TLSHandshakeTimeout   string `json:"tls-handshake-timeout,omitempty"`
+	ResponseHeaderTimeout string `json:"response-header-timeout,omitempty"`
+	Timeout               string `json:"timeout,omitempty"`
+	IndexTimeout          string `json:"index-timeout,omitempty"`
+	// Proxy URL which overrides environment variables like HTTPS_PROXY
+	Proxy string `json:"proxy,omitempty"`
+	// Path to PEM file of additional CA certificates
+	CABundle string `json:"ca-bundle,omitempty"`
+	// Text appended to User-Agent header
+	UserAgentSuffix string `json:"user-agent-suffix,omitempty"`
+	// Credentials keyed by host name optionally with port
+	Credentials map[string]Credential `json:"credentials,omitempty"`
+	// Path to .netrc file. Defaults to ~/.netrc
+	Netrc string `json:"netrc,omitempty"`
+}
+
+// Credential holds Bearer token, or username and password for Basic authentication
+type Credential struct {
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// DefaultPath returns the path of configuration file
+func DefaultPath() (path string) {
+	return filepath.Join(xdg.ConfigDir(), "config.json")
+}
+
+// Load reads configuration file on given path. It returns empty Config when the file does not exist
+func Load(path string) (cfg *Config, err error) {
+	cfg = &Config{configProps: &configProps{}, path: path}
+	raw, _err := ioutil.ReadFile(path)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return cfg, nil
+		}
+		return nil, erron.Errorwf(_err, "Can't read config file: %s", path)
+	}
+	if _err = json.Unmarshal(raw, cfg.configProps); _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to unmarshal JSON: %s", path)
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) String() string {
+	return fmt.Sprintf("%+v", *cfg.configProps)
+}
+
+// Path returns the file path where cfg is loaded from
+func (cfg *Config) Path() (path string) {
+	return cfg.path
+}
+
+// GetServer returns settings for the server. Trailing slash of URL is ignored on matching
+func (cfg *Config) GetServer(serverURL string) (svr Server) {
+	want := strings.TrimSuffix(serverURL, "/")
+	for key, s := range cfg.Servers {
+		if strings.TrimSuffix(key, "/") == want {
+			return s
+		}
+	}
+	return Server{}
+}
+
+// HTTPOptions converts HTTP settings into options for HTTP client
+func (cfg *Config) HTTPOptions() (opts http.Options, err error) {
+	h := cfg.HTTP
+	timeouts := []struct {
+		key string
+		val string
+		dst *time.Duration
+	}{
+		{"dial-timeout", h.DialTimeout, &opts.DialTimeout},
+		{"tls-handshake-timeout", h.TLSHandshakeTimeout, &opts.TLSHandshakeTimeout},
+		{"response-header-timeout", h.ResponseHeaderTimeout, &opts.ResponseHeaderTimeout},
+		{"timeout", h.Timeout, &opts.Timeout},
+		{"index-timeout", h.IndexTimeout, &opts.IndexTimeout},
+	}
+	for _, t := range timeouts {
+		if t.val == "" {
+			continue
+		}
+		d, _err := time.ParseDuration(t.val)
+		if _err != nil || d < 0 {
+			return opts, fmt.Errorf("Invalid http.%s: %s. Config: %s", t.key, t.val, cfg.path)
+		}
+		*t.dst = d
+	}
+	opts.Proxy = h.Proxy
+	opts.CABundle = h.CABundle
+	opts.UserAgentSuffix = h.UserAgentSuffix
+	for host, c := range h.Credentials {
+		opts.Credentials = append(opts.Credentials, http.Credential{
+			Host: host, Token: c.Token, Username: c.Username, Password: c.Password,
+		})
+	}
+	opts.Netrc = h.Netrc
+	return opts, nil
+}
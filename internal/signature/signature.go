@@ -0,0 +1,268 @@
+// Package signature makes and verifies detached signatures of files.
+// It supports minisign format and raw Ed25519 signatures like the ones cosign makes for blobs.
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/binqry/binq/internal/erron"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ErrVerificationFailed means that the signature does not match the content
+var ErrVerificationFailed = errors.New("Signature verification failed")
+
+// MaxMessageSize is the max size of content which VerifyReader reads into memory to verify
+// signature other than prehashed minisign one
+var MaxMessageSize int64 = 256 << 20
+
+const (
+	minisignAlgo          = "Ed"
+	minisignAlgoPrehashed = "ED"
+	minisignKeyIDSize     = 8
+	untrustedPrefix       = "untrusted comment:"
+	trustedPrefix         = "trusted comment: "
+)
+
+// PublicKey is a trusted Ed25519 public key. keyID is set only for minisign key
+type PublicKey struct {
+	key   ed25519.PublicKey
+	keyID []byte
+}
+
+// PrivateKey is an Ed25519 private key to sign files
+type PrivateKey struct {
+	key ed25519.PrivateKey
+}
+
+// ParsePublicKey parses public key in any of the following forms:
+//   - minisign public key with or without "untrusted comment:" line
+//   - base64 or hex encoded raw Ed25519 public key
+//   - PEM encoded PKIX public key like the one "openssl pkey -pubout" outputs
+func ParsePublicKey(s string) (pk *PublicKey, err error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		parsed, _err := x509.ParsePKIXPublicKey(block.Bytes)
+		if _err != nil {
+			return nil, erron.Errorwf(_err, "Failed to parse PEM public key")
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("Unsupported public key type: %T", parsed)
+		}
+		return &PublicKey{key: key}, nil
+	}
+
+	var encoded string
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, untrustedPrefix) {
+			continue
+		}
+		encoded = line
+		break
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("Public key is empty")
+	}
+
+	b, err := decodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("Can't decode public key: %s", encoded)
+	}
+	switch len(b) {
+	case ed25519.PublicKeySize:
+		return &PublicKey{key: ed25519.PublicKey(b)}, nil
+	case 2 + minisignKeyIDSize + ed25519.PublicKeySize:
+		if string(b[:2]) != minisignAlgo {
+			return nil, fmt.Errorf("Unsupported minisign key algorithm: %q", b[:2])
+		}
+		return &PublicKey{
+			key:   ed25519.PublicKey(b[2+minisignKeyIDSize:]),
+			keyID: b[2 : 2+minisignKeyIDSize],
+		}, nil
+	default:
+		return nil, fmt.Errorf("Wrong length of public key: %d bytes", len(b))
+	}
+}
+
+// ParsePrivateKey parses private key in any of the following forms:
+//   - base64 or hex encoded raw Ed25519 seed (32 bytes) or private key (64 bytes)
+//   - PEM encoded PKCS #8 private key like the one "openssl genpkey -algorithm ed25519" outputs
+func ParsePrivateKey(s string) (sk *PrivateKey, err error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		parsed, _err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if _err != nil {
+			return nil, erron.Errorwf(_err, "Failed to parse PEM private key")
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("Unsupported private key type: %T", parsed)
+		}
+		return &PrivateKey{key: key}, nil
+	}
+
+	b, err := decodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("Can't decode private key")
+	}
+	switch len(b) {
+	case ed25519.SeedSize:
+		return &PrivateKey{key: ed25519.NewKeyFromSeed(b)}, nil
+	case ed25519.PrivateKeySize:
+		return &PrivateKey{key: ed25519.PrivateKey(b)}, nil
+	default:
+		return nil, fmt.Errorf("Wrong length of private key: %d bytes", len(b))
+	}
+}
+
+// Sign returns base64 encoded raw Ed25519 signature of message with trailing newline
+func (sk *PrivateKey) Sign(message []byte) (sig []byte) {
+	encoded := base64.StdEncoding.EncodeToString(ed25519.Sign(sk.key, message))
+	return []byte(encoded + "\n")
+}
+
+// PublicKey returns base64 encoded public key paired with sk
+func (sk *PrivateKey) PublicKey() (encoded string) {
+	return base64.StdEncoding.EncodeToString(sk.key.Public().(ed25519.PublicKey))
+}
+
+// Verify checks sig for message. sig is either minisign signature file content, or raw Ed25519
+// signature in binary, base64 or hex form.
+// It returns error wrapping ErrVerificationFailed when the signature does not match.
+func (pk *PublicKey) Verify(message, sig []byte) (err error) {
+	if isMinisign(sig) {
+		ms, err := parseMinisign(sig)
+		if err != nil {
+			return err
+		}
+		if ms.algo == minisignAlgoPrehashed {
+			h := blake2b.Sum512(message)
+			return pk.verifyMinisign(ms, h[:])
+		}
+		return pk.verifyMinisign(ms, message)
+	}
+	return pk.verifyRaw(message, sig)
+}
+
+// VerifyReader works like Verify for the content read from r.
+// Prehashed minisign signature is verified by streaming the content. Other signatures sign the
+// whole content; so the content up to MaxMessageSize is read into memory.
+func (pk *PublicKey) VerifyReader(r io.Reader, sig []byte) (err error) {
+	if isMinisign(sig) {
+		ms, err := parseMinisign(sig)
+		if err != nil {
+			return err
+		}
+		if ms.algo == minisignAlgoPrehashed {
+			h, _ := blake2b.New512(nil)
+			if _, _err := io.Copy(h, r); _err != nil {
+				return erron.Errorwf(_err, "Failed to read content")
+			}
+			return pk.verifyMinisign(ms, h.Sum(nil))
+		}
+	}
+
+	message, _err := ioutil.ReadAll(io.LimitReader(r, MaxMessageSize+1))
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to read content")
+	}
+	if int64(len(message)) > MaxMessageSize {
+		return fmt.Errorf("Content is too large to verify signature of this type. Limit: %d bytes",
+			MaxMessageSize)
+	}
+	return pk.Verify(message, sig)
+}
+
+func (pk *PublicKey) verifyRaw(message, sig []byte) (err error) {
+	raw := sig
+	if len(raw) != ed25519.SignatureSize {
+		if raw, err = decodeString(string(bytes.TrimSpace(sig))); err != nil {
+			return fmt.Errorf("Can't decode signature")
+		}
+	}
+	if len(raw) != ed25519.SignatureSize {
+		return fmt.Errorf("Wrong length of signature: %d bytes", len(raw))
+	}
+	if !ed25519.Verify(pk.key, message, raw) {
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
+// minisignSig is parsed minisign signature file
+type minisignSig struct {
+	algo        string
+	keyID, body []byte
+	comment     string
+	global      []byte
+}
+
+func isMinisign(sig []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(sig), []byte(untrustedPrefix))
+}
+
+func parseMinisign(sig []byte) (ms *minisignSig, err error) {
+	lines := strings.Split(strings.TrimSpace(string(sig)), "\n")
+	if len(lines) < 4 {
+		return nil, fmt.Errorf("Malformed minisign signature. Lines: %d", len(lines))
+	}
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+
+	sigBlob, _err := base64.StdEncoding.DecodeString(lines[1])
+	if _err != nil || len(sigBlob) != 2+minisignKeyIDSize+ed25519.SignatureSize {
+		return nil, fmt.Errorf("Malformed minisign signature: %s", lines[1])
+	}
+	ms = &minisignSig{
+		algo:  string(sigBlob[:2]),
+		keyID: sigBlob[2 : 2+minisignKeyIDSize],
+		body:  sigBlob[2+minisignKeyIDSize:],
+	}
+	if ms.algo != minisignAlgo && ms.algo != minisignAlgoPrehashed {
+		return nil, fmt.Errorf("Unsupported minisign signature algorithm: %q", ms.algo)
+	}
+
+	if !strings.HasPrefix(lines[2], trustedPrefix) {
+		return nil, fmt.Errorf("Malformed minisign signature. Trusted comment is missing")
+	}
+	ms.comment = strings.TrimPrefix(lines[2], trustedPrefix)
+	if ms.global, _err = base64.StdEncoding.DecodeString(lines[3]); _err != nil ||
+		len(ms.global) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("Malformed minisign global signature: %s", lines[3])
+	}
+	return ms, nil
+}
+
+// verifyMinisign verifies ms for signed; which is the message itself or its BLAKE2b-512 hash
+// according to the algorithm of ms
+func (pk *PublicKey) verifyMinisign(ms *minisignSig, signed []byte) (err error) {
+	if pk.keyID != nil && !bytes.Equal(pk.keyID, ms.keyID) {
+		return erron.Errorwf(ErrVerificationFailed, "Key ID mismatch. Want: %X, Got: %X", pk.keyID, ms.keyID)
+	}
+	if !ed25519.Verify(pk.key, signed, ms.body) {
+		return ErrVerificationFailed
+	}
+	// Global signature covers the signature and the trusted comment
+	if !ed25519.Verify(pk.key, append(append([]byte{}, ms.body...), ms.comment...), ms.global) {
+		return erron.Errorwf(ErrVerificationFailed, "Trusted comment is tampered")
+	}
+	return nil
+}
+
+func decodeString(s string) (b []byte, err error) {
+	if b, err = hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
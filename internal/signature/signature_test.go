@@ -0,0 +1,158 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+var testKeyID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+// signMinisign makes minisign signature file content of message
+func signMinisign(priv ed25519.PrivateKey, message []byte, prehash bool, comment string) string {
+	if prehash {
+		h := blake2b.Sum512(message)
+		return signMinisignAs(priv, minisignAlgoPrehashed, h[:], comment)
+	}
+	return signMinisignAs(priv, minisignAlgo, message, comment)
+}
+
+// signMinisignAs makes minisign signature file content of algo for signed which is the message
+// or its hash
+func signMinisignAs(priv ed25519.PrivateKey, algo string, signed []byte, comment string) string {
+	body := ed25519.Sign(priv, signed)
+	blob := append(append([]byte(algo), testKeyID...), body...)
+	global := ed25519.Sign(priv, append(append([]byte{}, body...), comment...))
+	return fmt.Sprintf("untrusted comment: signature from test key\n%s\n%s%s\n%s\n",
+		base64.StdEncoding.EncodeToString(blob), trustedPrefix, comment,
+		base64.StdEncoding.EncodeToString(global))
+}
+
+func minisignPublicKey(pub ed25519.PublicKey) string {
+	blob := append(append([]byte(minisignAlgo), testKeyID...), pub...)
+	return fmt.Sprintf("untrusted comment: minisign public key\n%s\n",
+		base64.StdEncoding.EncodeToString(blob))
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key. %v", err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	message := []byte("#!/bin/sh\necho foo\n")
+	rawSig := ed25519.Sign(priv, message)
+	tampered := strings.Replace(
+		signMinisign(priv, message, true, "timestamp:1"), "timestamp:1", "timestamp:2", 1)
+
+	testCases := []struct {
+		key, sig string
+		message  []byte
+		fail     bool
+	}{
+		{key: minisignPublicKey(pub), sig: signMinisign(priv, message, false, "file:foo"), message: message},
+		{key: minisignPublicKey(pub), sig: signMinisign(priv, message, true, "file:foo"), message: message},
+		{key: minisignPublicKey(pub), sig: signMinisign(priv, message, true, "file:foo"), message: []byte("bar"), fail: true},
+		{key: minisignPublicKey(otherPub), sig: signMinisign(priv, message, true, "file:foo"), message: message, fail: true},
+		{key: minisignPublicKey(pub), sig: tampered, message: message, fail: true},
+		{key: base64.StdEncoding.EncodeToString(pub), sig: string(rawSig), message: message},
+		{key: hex.EncodeToString(pub), sig: base64.StdEncoding.EncodeToString(rawSig), message: message},
+		{key: hex.EncodeToString(pub), sig: hex.EncodeToString(rawSig), message: []byte("bar"), fail: true},
+	}
+	for i, tc := range testCases {
+		pk, err := ParsePublicKey(tc.key)
+		if err != nil {
+			t.Errorf("[%d] Failed to parse public key. %v", i, err)
+			continue
+		}
+		err = pk.Verify(tc.message, []byte(tc.sig))
+		if tc.fail != errors.Is(err, ErrVerificationFailed) || (!tc.fail && err != nil) {
+			t.Errorf("[%d] Unexpected result. Fail: %v, Error: %v", i, tc.fail, err)
+		}
+		err = pk.VerifyReader(bytes.NewReader(tc.message), []byte(tc.sig))
+		if tc.fail != errors.Is(err, ErrVerificationFailed) || (!tc.fail && err != nil) {
+			t.Errorf("[%d] Unexpected result of VerifyReader. Fail: %v, Error: %v", i, tc.fail, err)
+		}
+	}
+}
+
+func TestVerifyReaderLimit(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key. %v", err)
+	}
+	pk, err := ParsePublicKey(minisignPublicKey(pub))
+	if err != nil {
+		t.Fatalf("Failed to parse public key. %v", err)
+	}
+	orig := MaxMessageSize
+	MaxMessageSize = 1024
+	defer func() { MaxMessageSize = orig }()
+	message := bytes.Repeat([]byte("0123456789abcdef"), 1024)
+
+	// Prehashed signature is verified by streaming regardless of the limit
+	sig := signMinisign(priv, message, true, "file:large")
+	if err = pk.VerifyReader(bytes.NewReader(message), []byte(sig)); err != nil {
+		t.Errorf("Verification of prehashed signature failed. %v", err)
+	}
+	sig = signMinisign(priv, message, false, "file:large")
+	if err = pk.VerifyReader(bytes.NewReader(message), []byte(sig)); err == nil {
+		t.Errorf("Expected error for content larger than MaxMessageSize but got nil")
+	}
+}
+
+func TestParsePublicKeyError(t *testing.T) {
+	for _, key := range []string{"", "not-a-key", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParsePublicKey(key); err == nil {
+			t.Errorf("Error is expected for key: %q", key)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key. %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("Failed to marshal private key. %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key. %v", err)
+	}
+	pemPub := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	message := []byte(`{"items":[]}`)
+
+	for i, key := range []string{
+		hex.EncodeToString(priv.Seed()),
+		base64.StdEncoding.EncodeToString(priv),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	} {
+		sk, err := ParsePrivateKey(key)
+		if err != nil {
+			t.Errorf("[%d] Failed to parse private key. %v", i, err)
+			continue
+		}
+		for _, pubKey := range []string{sk.PublicKey(), pemPub} {
+			pk, err := ParsePublicKey(pubKey)
+			if err != nil {
+				t.Errorf("[%d] Failed to parse public key. %v", i, err)
+				continue
+			}
+			if err = pk.Verify(message, sk.Sign(message)); err != nil {
+				t.Errorf("[%d] Verification failed. %v", i, err)
+			}
+		}
+	}
+}
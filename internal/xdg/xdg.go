@@ -0,0 +1,51 @@
+// Package xdg resolves base directories for binq following XDG Base Directory Specification
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const appName = "binq"
+
+// DataHome returns $XDG_DATA_HOME or its default value "~/.local/share"
+func DataHome() (dir string) {
+	return baseDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// DataDir returns the directory to store persistent data of binq
+func DataDir() (dir string) {
+	return filepath.Join(DataHome(), appName)
+}
+
+// CacheHome returns $XDG_CACHE_HOME or its default value "~/.cache"
+func CacheHome() (dir string) {
+	return baseDir("XDG_CACHE_HOME", ".cache")
+}
+
+// CacheDir returns the directory to store cache data of binq
+func CacheDir() (dir string) {
+	return filepath.Join(CacheHome(), appName)
+}
+
+// ConfigHome returns $XDG_CONFIG_HOME or its default value "~/.config"
+func ConfigHome() (dir string) {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// ConfigDir returns the directory to store user configuration of binq
+func ConfigDir() (dir string) {
+	return filepath.Join(ConfigHome(), appName)
+}
+
+func baseDir(envKey string, defaultPath ...string) (dir string) {
+	if dir = os.Getenv(envKey); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// Fallback to current directory
+		home = "."
+	}
+	return filepath.Join(append([]string{home}, defaultPath...)...)
+}
@@ -3,11 +3,15 @@ package item
 import (
 	"crypto/md5"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
 	"hash"
 	"hash/crc32"
+	"io"
 	"strings"
 
-	"github.com/progrhyme/go-lv"
+	"golang.org/x/crypto/blake2b"
 )
 
 type ChecksumType int
@@ -16,58 +20,206 @@ const (
 	ChecksumTypeSHA256 ChecksumType = iota + 1
 	ChecksumTypeCRC
 	ChecksumTypeMD5
+	ChecksumTypeSHA512
+	ChecksumTypeBLAKE2b256
+	ChecksumTypeBLAKE2b512
 	ChecksumTypeUnknown ChecksumType = -1
 )
 
+// ParseChecksumType returns ChecksumType corresponding to the name of algorithm
+func ParseChecksumType(name string) (t ChecksumType) {
+	switch name {
+	case "sha256", "SHA256", "SHA-256":
+		return ChecksumTypeSHA256
+	case "crc", "CRC":
+		return ChecksumTypeCRC
+	case "md5", "MD5":
+		return ChecksumTypeMD5
+	case "sha512", "SHA512", "SHA-512":
+		return ChecksumTypeSHA512
+	case "blake2b-256", "BLAKE2b-256", "BLAKE2B-256":
+		return ChecksumTypeBLAKE2b256
+	case "blake2b-512", "BLAKE2b-512", "BLAKE2B-512", "blake2b", "BLAKE2b", "BLAKE2B":
+		return ChecksumTypeBLAKE2b512
+	default:
+		return ChecksumTypeUnknown
+	}
+}
+
+func (t ChecksumType) String() string {
+	switch t {
+	case ChecksumTypeSHA256:
+		return "sha256"
+	case ChecksumTypeCRC:
+		return "crc"
+	case ChecksumTypeMD5:
+		return "md5"
+	case ChecksumTypeSHA512:
+		return "sha512"
+	case ChecksumTypeBLAKE2b256:
+		return "blake2b-256"
+	case ChecksumTypeBLAKE2b512:
+		return "blake2b-512"
+	default:
+		return "unknown"
+	}
+}
+
 type ItemChecksum struct {
-	File   string `json:"file"`
-	SHA256 string `json:"sha256,omitempty"`
+	File       string `json:"file"`
+	SHA256     string `json:"sha256,omitempty"`
+	SHA512     string `json:"sha512,omitempty"`
+	BLAKE2b256 string `json:"blake2b-256,omitempty"`
+	BLAKE2b512 string `json:"blake2b-512,omitempty"`
 	// CRC-32 IEEE Std.
 	CRC string `json:"crc,omitempty"`
 	MD5 string `json:"md5,omitempty"`
 }
 
-func NewItemChecksums(arg string) (sums []ItemChecksum) {
+// NewItemChecksums parses argument like "<File1>:<Checksum1>[:<Algorithm1>],...".
+// Checksums of different algorithms for the same file are merged into one ItemChecksum.
+// It fails when any entry is malformed or has unsupported algorithm
+func NewItemChecksums(arg string) (sums []ItemChecksum, err error) {
 	if arg == "" {
-		return nil
+		return nil, nil
 	}
 
 	for _, entry := range strings.Split(arg, ",") {
 		params := strings.Split(entry, ":")
+		var t ChecksumType
 		switch len(params) {
 		case 2:
-			sums = append(sums, ItemChecksum{File: params[0], SHA256: params[1]})
+			t = ChecksumTypeSHA256
 		case 3:
-			switch params[2] {
-			case "sha256", "SHA256", "SHA-256":
-				sums = append(sums, ItemChecksum{File: params[0], SHA256: params[1]})
-			case "crc", "CRC":
-				sums = append(sums, ItemChecksum{File: params[0], CRC: params[1]})
-			case "md5", "MD5":
-				sums = append(sums, ItemChecksum{File: params[0], MD5: params[1]})
-			default:
-				lv.Warnf("Unsupported algorithm: %s. Param: %s", params[2], entry)
+			t = ParseChecksumType(params[2])
+			if t == ChecksumTypeUnknown {
+				return nil, fmt.Errorf("Unsupported algorithm: %s. Param: %s", params[2], entry)
 			}
-
 		default:
-			lv.Warnf("Wrong argement for replacement: %s", entry)
+			return nil, fmt.Errorf("Wrong argument for checksum: %s", entry)
 		}
+
+		if sums, err = mergeChecksum(sums, params[0], params[1], t); err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
+
+// ChecksumTypes lists supported checksum algorithms in order of preference
+var ChecksumTypes = []ChecksumType{
+	ChecksumTypeSHA256,
+	ChecksumTypeSHA512,
+	ChecksumTypeBLAKE2b512,
+	ChecksumTypeBLAKE2b256,
+	ChecksumTypeCRC,
+	ChecksumTypeMD5,
+}
+
+// Digest calculates checksum by an algorithm and compares it with the expected one
+type Digest struct {
+	Type     ChecksumType
+	Expected string
+	hasher   hash.Hash
+}
+
+// NewDigest returns Digest for the algorithm. It returns nil for unsupported algorithm
+func NewDigest(t ChecksumType, expected string) (d *Digest) {
+	h := newHasher(t)
+	if h == nil {
+		return nil
 	}
-	return sums
+	return &Digest{Type: t, Expected: expected, hasher: h}
+}
+
+func (d *Digest) Write(p []byte) (n int, err error) {
+	return d.hasher.Write(p)
+}
+
+// Sum returns hex encoded checksum of written data
+func (d *Digest) Sum() (s string) {
+	return hex.EncodeToString(d.hasher.Sum(nil))
 }
 
+// Match reports whether calculated checksum equals to the expected one
+func (d *Digest) Match() bool {
+	return d.Sum() == d.Expected
+}
+
+// DigestWriter returns io.Writer which writes data to all of given digests so that content can
+// be hashed by multiple algorithms at once
+func DigestWriter(digests []*Digest) (w io.Writer) {
+	writers := make([]io.Writer, len(digests))
+	for i, d := range digests {
+		writers[i] = d
+	}
+	return io.MultiWriter(writers...)
+}
+
+func newHasher(t ChecksumType) (h hash.Hash) {
+	switch t {
+	case ChecksumTypeSHA256:
+		return sha256.New()
+	case ChecksumTypeCRC:
+		return crc32.NewIEEE()
+	case ChecksumTypeMD5:
+		return md5.New()
+	case ChecksumTypeSHA512:
+		return sha512.New()
+	case ChecksumTypeBLAKE2b256:
+		// Error never occurs without key
+		h, _ = blake2b.New256(nil)
+		return h
+	case ChecksumTypeBLAKE2b512:
+		h, _ = blake2b.New512(nil)
+		return h
+	default:
+		return nil
+	}
+}
+
+// GetSumAndHasher returns the first declared checksum in order of preference with its hasher
 func (sum *ItemChecksum) GetSumAndHasher() (s string, h hash.Hash, t ChecksumType) {
-	if sum.SHA256 != "" {
-		return sum.SHA256, sha256.New(), ChecksumTypeSHA256
-	} else if sum.CRC != "" {
-		return sum.CRC, crc32.NewIEEE(), ChecksumTypeCRC
-	} else if sum.MD5 != "" {
-		return sum.MD5, md5.New(), ChecksumTypeMD5
+	for _, t := range ChecksumTypes {
+		if s = sum.GetSum(t); s != "" {
+			return s, newHasher(t), t
+		}
 	}
 	return "", nil, ChecksumTypeUnknown
 }
 
-func (sum *ItemChecksum) SetSum(val string, t ChecksumType) {
+// GetDigests returns Digests for all declared checksums
+func (sum *ItemChecksum) GetDigests() (digests []*Digest) {
+	for _, t := range ChecksumTypes {
+		if s := sum.GetSum(t); s != "" {
+			digests = append(digests, NewDigest(t, s))
+		}
+	}
+	return digests
+}
+
+// GetSum returns declared checksum of the algorithm
+func (sum *ItemChecksum) GetSum(t ChecksumType) (s string) {
+	switch t {
+	case ChecksumTypeSHA256:
+		return sum.SHA256
+	case ChecksumTypeCRC:
+		return sum.CRC
+	case ChecksumTypeMD5:
+		return sum.MD5
+	case ChecksumTypeSHA512:
+		return sum.SHA512
+	case ChecksumTypeBLAKE2b256:
+		return sum.BLAKE2b256
+	case ChecksumTypeBLAKE2b512:
+		return sum.BLAKE2b512
+	default:
+		return ""
+	}
+}
+
+// SetSum sets checksum val of algorithm t. It fails when t is not supported
+func (sum *ItemChecksum) SetSum(val string, t ChecksumType) (err error) {
 	switch t {
 	case ChecksumTypeSHA256:
 		sum.SHA256 = val
@@ -75,8 +227,14 @@ func (sum *ItemChecksum) SetSum(val string, t ChecksumType) {
 		sum.CRC = val
 	case ChecksumTypeMD5:
 		sum.MD5 = val
+	case ChecksumTypeSHA512:
+		sum.SHA512 = val
+	case ChecksumTypeBLAKE2b256:
+		sum.BLAKE2b256 = val
+	case ChecksumTypeBLAKE2b512:
+		sum.BLAKE2b512 = val
 	default:
-		// Unexpected
-		lv.Fatalf("Unsupported type for checksum: %d", t)
+		return fmt.Errorf("Unsupported type for checksum: %d", t)
 	}
+	return nil
 }
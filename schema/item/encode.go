@@ -10,10 +10,17 @@ func GenerateItemJSON(rev *ItemRevision, pretty bool) (b []byte, err error) {
 	var _err error
 	prop := itemProps{
 		Meta: itemMeta{
-			URLFormat:    rev.URLFormat,
-			Replacements: rev.Replacements,
-			Extension:    rev.Extension,
-			RenameFiles:  rev.RenameFiles,
+			URLFormat:          rev.URLFormat,
+			ChecksumURLFormat:  rev.ChecksumURLFormat,
+			SignatureURLFormat: rev.SignatureURLFormat,
+			PublicKey:          rev.PublicKey,
+			Replacements:       rev.Replacements,
+			Extension:          rev.Extension,
+			RenameFiles:        rev.RenameFiles,
+			Files:              rev.Files,
+			AuxFiles:           rev.AuxFiles,
+			StripComponents:    rev.StripComponents,
+			Subdir:             rev.Subdir,
 		},
 		Latest: itemLatestRevision{Version: rev.Version},
 		Versions: []ItemRevision{
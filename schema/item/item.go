@@ -60,21 +60,35 @@ func (i *Item) GetLatest() (rev *ItemRevision) {
 	}
 
 	return &ItemRevision{
-		Version:      latest.Version,
-		URLFormat:    i.Meta.URLFormat,
-		Replacements: i.Meta.Replacements,
-		Extension:    i.Meta.Extension,
-		RenameFiles:  i.Meta.RenameFiles,
+		Version:            latest.Version,
+		URLFormat:          i.Meta.URLFormat,
+		ChecksumURLFormat:  i.Meta.ChecksumURLFormat,
+		SignatureURLFormat: i.Meta.SignatureURLFormat,
+		PublicKey:          i.Meta.PublicKey,
+		Replacements:       i.Meta.Replacements,
+		Extension:          i.Meta.Extension,
+		RenameFiles:        i.Meta.RenameFiles,
+		Files:              i.Meta.Files,
+		AuxFiles:           i.Meta.AuxFiles,
+		StripComponents:    i.Meta.StripComponents,
+		Subdir:             i.Meta.Subdir,
 	}
 }
 
 func (i *Item) GetRevision(version string) (rev *ItemRevision) {
 	tmp := &ItemRevision{
-		Version:      version,
-		URLFormat:    i.Meta.URLFormat,
-		Replacements: i.Meta.Replacements,
-		Extension:    i.Meta.Extension,
-		RenameFiles:  i.Meta.RenameFiles,
+		Version:            version,
+		URLFormat:          i.Meta.URLFormat,
+		ChecksumURLFormat:  i.Meta.ChecksumURLFormat,
+		SignatureURLFormat: i.Meta.SignatureURLFormat,
+		PublicKey:          i.Meta.PublicKey,
+		Replacements:       i.Meta.Replacements,
+		Extension:          i.Meta.Extension,
+		RenameFiles:        i.Meta.RenameFiles,
+		Files:              i.Meta.Files,
+		AuxFiles:           i.Meta.AuxFiles,
+		StripComponents:    i.Meta.StripComponents,
+		Subdir:             i.Meta.Subdir,
 	}
 
 	found := false
@@ -85,6 +99,15 @@ func (i *Item) GetRevision(version string) (rev *ItemRevision) {
 			if ver.URLFormat != "" {
 				tmp.URLFormat = ver.URLFormat
 			}
+			if ver.ChecksumURLFormat != "" {
+				tmp.ChecksumURLFormat = ver.ChecksumURLFormat
+			}
+			if ver.SignatureURLFormat != "" {
+				tmp.SignatureURLFormat = ver.SignatureURLFormat
+			}
+			if ver.PublicKey != "" {
+				tmp.PublicKey = ver.PublicKey
+			}
 			if ver.Replacements != nil {
 				tmp.Replacements = ver.Replacements
 			}
@@ -94,6 +117,18 @@ func (i *Item) GetRevision(version string) (rev *ItemRevision) {
 			if ver.RenameFiles != nil {
 				tmp.RenameFiles = ver.RenameFiles
 			}
+			if ver.Files != nil {
+				tmp.Files = ver.Files
+			}
+			if ver.AuxFiles != nil {
+				tmp.AuxFiles = ver.AuxFiles
+			}
+			if ver.StripComponents != nil {
+				tmp.StripComponents = ver.StripComponents
+			}
+			if ver.Subdir != "" {
+				tmp.Subdir = ver.Subdir
+			}
 			break
 		}
 	}
@@ -104,7 +139,13 @@ func (i *Item) GetRevision(version string) (rev *ItemRevision) {
 	return nil
 }
 
-func (i *Item) AddOrUpdateRevision(rev *ItemRevision, mode ReviseMode) {
+// AddOrUpdateRevision adds rev into versions or replaces the one of the same version. Its
+// position among versions is determined by mode. logger receives notices on unparsable versions
+func (i *Item) AddOrUpdateRevision(rev *ItemRevision, mode ReviseMode, logger lv.Granular) (err error) {
+	if mode != ReviseModeLatest && mode != ReviseModeOld && mode != ReviseModeNatural {
+		return fmt.Errorf("Invalid mode: %v", mode)
+	}
+
 	var replaced bool
 	for idx, rv := range i.Versions {
 		if rv.Version == rev.Version {
@@ -125,11 +166,9 @@ func (i *Item) AddOrUpdateRevision(rev *ItemRevision, mode ReviseMode) {
 		if replaced {
 			break
 		}
-		i.addNotLatestRevision(rev, mode)
-
-	default:
-		lv.Fatalf("Fatal Error! Invalid mode: %v", mode)
+		i.addNotLatestRevision(rev, mode, logger)
 	}
+	return nil
 }
 
 func (i *Item) UpdateRevisionChecksum(ver string, sum *ItemChecksum) (success bool) {
@@ -143,14 +182,31 @@ func (i *Item) UpdateRevisionChecksum(ver string, sum *ItemChecksum) (success bo
 	return false
 }
 
-func (i *Item) addNotLatestRevision(rev *ItemRevision, mode ReviseMode) {
+// MergeRevisionChecksums merges sums into checksums of the version.
+// It returns true when any checksum is added or changed
+func (i *Item) MergeRevisionChecksums(ver string, sums []ItemChecksum) (changed bool) {
+	for idx, rev := range i.Versions {
+		if rev.Version == ver {
+			for _, sum := range sums {
+				if rev.MergeChecksum(&sum) {
+					changed = true
+				}
+			}
+			i.Versions[idx] = rev
+			return changed
+		}
+	}
+	return false
+}
+
+func (i *Item) addNotLatestRevision(rev *ItemRevision, mode ReviseMode, logger lv.Granular) {
 	newVer, err := version.NewVersion(rev.Version)
 	if err != nil {
-		lv.Noticef("Given version is not parsed as version")
+		logger.Noticef("Given version is not parsed as version")
 		for idx, rv := range i.Versions {
 			_, err := version.NewVersion(rv.Version)
 			if err != nil {
-				lv.Debugf("Fail to parse version: %s", rv.Version)
+				logger.Debugf("Fail to parse version: %s", rv.Version)
 				// Insert before rv
 				i.Versions = append(i.Versions[:idx], append([]ItemRevision{*rev}, i.Versions[idx:]...)...)
 				if idx == 0 {
@@ -168,7 +224,7 @@ func (i *Item) addNotLatestRevision(rev *ItemRevision, mode ReviseMode) {
 		rv := i.Versions[idx]
 		v, err := version.NewVersion(rv.Version)
 		if err != nil {
-			lv.Noticef("Cannot parse version: %s", rv.Version)
+			logger.Noticef("Cannot parse version: %s", rv.Version)
 			continue
 		}
 		if v.GreaterThan(newVer) {
@@ -5,16 +5,30 @@ import (
 	"strings"
 
 	"github.com/binqry/binq/internal/erron"
-	"github.com/progrhyme/go-lv"
 )
 
 type ItemRevision struct {
-	Version      string            `json:"version"`
-	Checksums    []ItemChecksum    `json:"checksums,omitempty"`
-	URLFormat    string            `json:"url-format,omitempty"`
+	Version   string         `json:"version"`
+	Checksums []ItemChecksum `json:"checksums,omitempty"`
+	URLFormat string         `json:"url-format,omitempty"`
+	// Format of URL of checksum file like "SHA256SUMS" which upstream publishes
+	ChecksumURLFormat string `json:"checksum-url-format,omitempty"`
+	// Format of URL of detached signature of the file
+	SignatureURLFormat string `json:"signature-url-format,omitempty"`
+	// Trusted public key to verify signature. minisign or raw Ed25519 key
+	PublicKey    string            `json:"public-key,omitempty"`
 	Replacements map[string]string `json:"replacements,omitempty"`
 	Extension    map[string]string `json:"extension,omitempty"`
 	RenameFiles  map[string]string `json:"rename-files,omitempty"`
+	// Glob patterns of paths in archive to install. A pattern without "/" matches base name
+	Files []string `json:"files,omitempty"`
+	// Glob patterns of auxiliary files in archive keyed by role like "man" and "bash-completion"
+	AuxFiles map[string]string `json:"aux-files,omitempty"`
+	// Number of leading path components stripped from archive on installation without executables.
+	// Nil means unset; so that 0 in a version can override the one in meta
+	StripComponents *int `json:"strip-components,omitempty"`
+	// Subdirectory in archive to install without executables
+	Subdir string `json:"subdir,omitempty"`
 }
 
 func (rev *ItemRevision) GetChecksum(file string) (sum *ItemChecksum) {
@@ -36,24 +50,92 @@ func (rev *ItemRevision) AddOrSwapChecksum(sum *ItemChecksum) {
 	rev.Checksums = append(rev.Checksums, *sum)
 }
 
+// MergeChecksum merges checksums in sum into the one for the same file.
+// It returns true when any checksum is added or changed
+func (rev *ItemRevision) MergeChecksum(sum *ItemChecksum) (changed bool) {
+	for i, cs := range rev.Checksums {
+		if cs.File != sum.File {
+			continue
+		}
+		for _, d := range sum.GetDigests() {
+			if cs.GetSum(d.Type) != d.Expected {
+				// Never fails because digests have only supported types
+				rev.Checksums[i].SetSum(d.Expected, d.Type)
+				changed = true
+			}
+		}
+		return changed
+	}
+	rev.Checksums = append(rev.Checksums, *sum)
+	return true
+}
+
 func (rev *ItemRevision) GetURL(param FormatParam) (url string, err error) {
 	return rev.applyFormat(rev.URLFormat, param)
 }
 
-func (rev *ItemRevision) ConvertFileName(src string, param FormatParam) (dest string) {
+// GetChecksumURL returns URL of checksum file. It returns empty string when the format is not set
+func (rev *ItemRevision) GetChecksumURL(param FormatParam) (url string, err error) {
+	if rev.ChecksumURLFormat == "" {
+		return "", nil
+	}
+	return rev.applyFormat(rev.ChecksumURLFormat, param)
+}
+
+// GetSignatureURL returns URL of signature file. It returns empty string when the format is not set
+func (rev *ItemRevision) GetSignatureURL(param FormatParam) (url string, err error) {
+	if rev.SignatureURLFormat == "" {
+		return "", nil
+	}
+	return rev.applyFormat(rev.SignatureURLFormat, param)
+}
+
+// ConvertFileName returns the file name to rename src to by "rename-files". It returns empty
+// string when src is not renamed. Malformed format in "rename-files" is an error
+func (rev *ItemRevision) ConvertFileName(src string, param FormatParam) (dest string, err error) {
 	for namef, val := range rev.RenameFiles {
 		name, err := rev.applyFormat(namef, param)
 		if err != nil {
-			lv.Errorf("%s", err)
-			continue
+			return "", err
 		}
 		if name == src {
-			return val
+			return val, nil
 		}
 	}
 
 	// No rename
-	return ""
+	return "", nil
+}
+
+// GetFilePatterns returns "files" with the format applied to each pattern
+func (rev *ItemRevision) GetFilePatterns(param FormatParam) (patterns []string, err error) {
+	for _, f := range rev.Files {
+		pattern, err := rev.applyFormat(f, param)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// GetAuxFilePatterns returns "aux-files" with the format applied to each pattern
+func (rev *ItemRevision) GetAuxFilePatterns(param FormatParam) (patterns map[string]string, err error) {
+	patterns = make(map[string]string)
+	for role, f := range rev.AuxFiles {
+		if patterns[role], err = rev.applyFormat(f, param); err != nil {
+			return nil, err
+		}
+	}
+	return patterns, nil
+}
+
+// GetSubdir returns "subdir" with the format applied
+func (rev *ItemRevision) GetSubdir(param FormatParam) (subdir string, err error) {
+	if rev.Subdir == "" {
+		return "", nil
+	}
+	return rev.applyFormat(rev.Subdir, param)
 }
 
 func (rev *ItemRevision) applyFormat(format string, param FormatParam) (applied string, err error) {
@@ -0,0 +1,129 @@
+package item
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// BSD-style line like "SHA256 (foo.zip) = 0123abcd..."
+	reBSDSumLine = regexp.MustCompile(`^([\w\-]+) ?\((.+)\) ?= ?([0-9a-fA-F]+)$`)
+	// GNU coreutils-style line like "0123abcd...  foo.zip" or "0123abcd... *foo.zip"
+	reGNUSumLine = regexp.MustCompile(`^([0-9a-fA-F]+)(?:\s+\*?(.+))?$`)
+)
+
+// GuessChecksumType guesses algorithm of checksum file by its name like "SHA256SUMS" or
+// "foo.zip.sha512". The name is split into tokens by ".", "-" and "_" so that only a whole
+// token like "b2sums" or "blake2b" is recognized. It returns ChecksumTypeUnknown when it
+// can't be guessed
+func GuessChecksumType(name string) (t ChecksumType) {
+	tokens := map[string]bool{}
+	for _, tok := range strings.FieldsFunc(strings.ToLower(path.Base(name)), func(r rune) bool {
+		return r == '.' || r == '-' || r == '_'
+	}) {
+		// "sha256sums" and "sha256sum" are the same as "sha256"
+		if trimmed := strings.TrimSuffix(strings.TrimSuffix(tok, "s"), "sum"); trimmed != "" {
+			tok = trimmed
+		}
+		tokens[tok] = true
+	}
+	switch {
+	case tokens["sha512"]:
+		return ChecksumTypeSHA512
+	case tokens["sha256"]:
+		return ChecksumTypeSHA256
+	case tokens["blake2b256"]:
+		return ChecksumTypeBLAKE2b256
+	case tokens["b2"], tokens["blake2b"]:
+		if tokens["256"] {
+			return ChecksumTypeBLAKE2b256
+		}
+		return ChecksumTypeBLAKE2b512
+	case tokens["blake2b512"]:
+		return ChecksumTypeBLAKE2b512
+	case tokens["md5"]:
+		return ChecksumTypeMD5
+	default:
+		return ChecksumTypeUnknown
+	}
+}
+
+// checksumTypeByLength guesses algorithm by the length of hex string
+func checksumTypeByLength(sum string) (t ChecksumType) {
+	switch len(sum) {
+	case 8:
+		return ChecksumTypeCRC
+	case 32:
+		return ChecksumTypeMD5
+	case 64:
+		return ChecksumTypeSHA256
+	case 128:
+		return ChecksumTypeSHA512
+	default:
+		return ChecksumTypeUnknown
+	}
+}
+
+// ParseSumFile parses content of checksum file in GNU coreutils or BSD style.
+// Algorithm of GNU-style lines is t; and guessed by the length of checksum when t is
+// ChecksumTypeUnknown. A line which has only checksum is regarded as the one for defaultFile.
+// Checksums for the same file are merged into one ItemChecksum.
+func ParseSumFile(content []byte, t ChecksumType, defaultFile string) (sums []ItemChecksum, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var file, sum string
+		kind := t
+		if m := reBSDSumLine.FindStringSubmatch(line); m != nil {
+			kind = ParseChecksumType(m[1])
+			file, sum = m[2], m[3]
+		} else if m := reGNUSumLine.FindStringSubmatch(line); m != nil {
+			sum, file = m[1], m[2]
+			if file == "" {
+				file = defaultFile
+			}
+		} else {
+			return nil, fmt.Errorf("Unrecognized format at line %d: %s", n, line)
+		}
+		if kind == ChecksumTypeUnknown {
+			kind = checksumTypeByLength(sum)
+		}
+		if kind == ChecksumTypeUnknown {
+			return nil, fmt.Errorf("Can't determine algorithm at line %d: %s", n, line)
+		}
+		if file == "" {
+			return nil, fmt.Errorf("File name is missing at line %d: %s", n, line)
+		}
+		// Strip directory like "./foo.zip" or "dist/foo.zip"
+		file = path.Base(file)
+
+		if sums, err = mergeChecksum(sums, file, strings.ToLower(sum), kind); err != nil {
+			return nil, err
+		}
+	}
+	if _err := scanner.Err(); _err != nil {
+		return nil, _err
+	}
+	return sums, nil
+}
+
+func mergeChecksum(sums []ItemChecksum, file, val string, t ChecksumType) (merged []ItemChecksum, err error) {
+	for i := range sums {
+		if sums[i].File == file {
+			return sums, sums[i].SetSum(val, t)
+		}
+	}
+	sum := ItemChecksum{File: file}
+	if err = sum.SetSum(val, t); err != nil {
+		return sums, err
+	}
+	return append(sums, sum), nil
+}
@@ -0,0 +1,28 @@
+package item
+
+import "testing"
+
+func TestGuessChecksumType(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want ChecksumType
+	}{
+		{"SHA256SUMS", ChecksumTypeSHA256},
+		{"https://example.com/foo-1.0.0.zip.sha512", ChecksumTypeSHA512},
+		{"foo_1.0.0_checksums_sha256.txt", ChecksumTypeSHA256},
+		{"MD5SUMS", ChecksumTypeMD5},
+		{"B2SUMS", ChecksumTypeBLAKE2b512},
+		{"foo.b2sum", ChecksumTypeBLAKE2b512},
+		{"foo.zip.blake2b", ChecksumTypeBLAKE2b512},
+		{"foo-blake2b-256.txt", ChecksumTypeBLAKE2b256},
+		{"foo.blake2b256", ChecksumTypeBLAKE2b256},
+		{"foo-v1b2-checksums.txt", ChecksumTypeUnknown},
+		{"sb2-1.0.0.sums", ChecksumTypeUnknown},
+		{"fooblake2b.txt", ChecksumTypeUnknown},
+		{"checksums.txt", ChecksumTypeUnknown},
+	} {
+		if got := GuessChecksumType(tc.name); got != tc.want {
+			t.Errorf("GuessChecksumType(%q) = %v; want %v", tc.name, got, tc.want)
+		}
+	}
+}
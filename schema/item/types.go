@@ -22,10 +22,17 @@ type itemProps struct {
 }
 
 type itemMeta struct {
-	URLFormat    string            `json:"url-format,omitempty"`
-	Replacements map[string]string `json:"replacements,omitempty"`
-	Extension    map[string]string `json:"extension,omitempty"`
-	RenameFiles  map[string]string `json:"rename-files,omitempty"`
+	URLFormat          string            `json:"url-format,omitempty"`
+	ChecksumURLFormat  string            `json:"checksum-url-format,omitempty"`
+	SignatureURLFormat string            `json:"signature-url-format,omitempty"`
+	PublicKey          string            `json:"public-key,omitempty"`
+	Replacements       map[string]string `json:"replacements,omitempty"`
+	Extension          map[string]string `json:"extension,omitempty"`
+	RenameFiles        map[string]string `json:"rename-files,omitempty"`
+	Files              []string          `json:"files,omitempty"`
+	AuxFiles           map[string]string `json:"aux-files,omitempty"`
+	StripComponents    *int              `json:"strip-components,omitempty"`
+	Subdir             string            `json:"subdir,omitempty"`
 }
 
 type itemLatestRevision struct {
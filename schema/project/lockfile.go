@@ -0,0 +1,134 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/binqry/binq/internal/atomicfile"
+	"github.com/binqry/binq/internal/erron"
+	"github.com/binqry/binq/schema/item"
+)
+
+// LockfileName is the default file name of Lockfile placed next to Toolfile
+const LockfileName = "binq.lock"
+
+// Lockfile wraps lockfileProps which corresponds to JSON structure of lockfile.
+// It pins resolved download URLs and checksums of items for reproducible installation.
+type Lockfile struct {
+	*lockfileProps
+	path string
+}
+
+type lockfileProps struct {
+	Items []LockedItem `json:"items"`
+}
+
+// LockedItem represents a resolved version of an item on index server
+type LockedItem struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Server  string `json:"server,omitempty"`
+	// Path of the item on index server
+	Path string `json:"path,omitempty"`
+	// Resolved artifacts keyed by platform string "OS/Arch"
+	Platforms map[string]LockedArtifact `json:"platforms"`
+}
+
+// LockedArtifact represents a resolved download for a platform
+type LockedArtifact struct {
+	URL       string `json:"url"`
+	Algorithm string `json:"algorithm"`
+	Digest    string `json:"digest"`
+}
+
+// Platform returns the key of LockedItem.Platforms
+func Platform(os, arch string) (key string) {
+	return fmt.Sprintf("%s/%s", os, arch)
+}
+
+// LockfilePathFor returns the path of Lockfile placed next to given Toolfile
+func LockfilePathFor(toolfile string) (path string) {
+	return filepath.Join(filepath.Dir(toolfile), LockfileName)
+}
+
+// LoadLockfile reads Lockfile on given path. It returns empty Lockfile when the file does not exist
+func LoadLockfile(path string) (lf *Lockfile, err error) {
+	lf = &Lockfile{lockfileProps: &lockfileProps{Items: []LockedItem{}}, path: path}
+	raw, _err := ioutil.ReadFile(path)
+	if _err != nil {
+		if os.IsNotExist(_err) {
+			return lf, nil
+		}
+		return nil, erron.Errorwf(_err, "Can't read Lockfile: %s", path)
+	}
+	if _err = json.Unmarshal(raw, lf.lockfileProps); _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to unmarshal JSON: %s", path)
+	}
+	return lf, nil
+}
+
+func (lf *Lockfile) String() string {
+	return fmt.Sprintf("%+v", *lf.lockfileProps)
+}
+
+// Save writes lf into its file
+func (lf *Lockfile) Save() (err error) {
+	b, _err := json.MarshalIndent(lf.lockfileProps, "", "  ")
+	if _err != nil {
+		return erron.Errorwf(_err, "Failed to marshal JSON: %s", lf)
+	}
+	if _err = atomicfile.WriteFile(lf.path, append(b, '\n'), 0644); _err != nil {
+		return erron.Errorwf(_err, "Can't write Lockfile: %s", lf.path)
+	}
+	return nil
+}
+
+// Find returns LockedItem which has given name
+func (lf *Lockfile) Find(name string) (locked *LockedItem) {
+	for _, i := range lf.Items {
+		if i.Name == name {
+			return &i
+		}
+	}
+	return nil
+}
+
+// Put adds or replaces LockedItem which has the same name
+func (lf *Lockfile) Put(locked LockedItem) {
+	for idx, i := range lf.Items {
+		if i.Name == locked.Name {
+			lf.Items[idx] = locked
+			return
+		}
+	}
+	lf.Items = append(lf.Items, locked)
+	sort.SliceStable(lf.Items, func(i, j int) bool { return lf.Items[i].Name < lf.Items[j].Name })
+}
+
+// Remove deletes LockedItem which has given name
+func (lf *Lockfile) Remove(name string) (success bool) {
+	for idx, i := range lf.Items {
+		if i.Name == name {
+			lf.Items = append(lf.Items[:idx], lf.Items[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Checksum converts la into ItemChecksum for the file
+func (la LockedArtifact) Checksum(file string) (sum *item.ItemChecksum, err error) {
+	t := item.ParseChecksumType(la.Algorithm)
+	if t == item.ChecksumTypeUnknown {
+		return nil, fmt.Errorf("Unsupported algorithm in Lockfile: %s", la.Algorithm)
+	}
+	sum = &item.ItemChecksum{File: file}
+	if err = sum.SetSum(la.Digest, t); err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
@@ -0,0 +1,160 @@
+// Package project defines schema of project files which declare tools to be installed by binq.
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/binqry/binq/internal/erron"
+)
+
+// ToolfileNames are the file names of Toolfile in order of precedence
+var ToolfileNames = []string{"binq.json", "Binqfile"}
+
+// DefaultToolDir is the directory to install tools when it is not specified in Toolfile.
+// Relative path is resolved from the directory of Toolfile.
+const DefaultToolDir = "bin"
+
+var reToolSpec = regexp.MustCompile(`^([\w\-\./]+)@([\w\-\.]+)$`)
+
+// Toolfile wraps toolfileProps which corresponds to JSON structure of project manifest
+type Toolfile struct {
+	*toolfileProps
+	path string
+}
+
+type toolfileProps struct {
+	Server string `json:"server,omitempty"`
+	Dir    string `json:"dir,omitempty"`
+	Tools  []Tool `json:"items"`
+}
+
+// Tool represents an item declared in Toolfile.
+// In JSON, it can be written either as an object or a string like "NAME[@VERSION]".
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Server  string `json:"server,omitempty"`
+	Dir     string `json:"dir,omitempty"`
+	// File name to rename the installed executable
+	File string `json:"file,omitempty"`
+	// Glob patterns of files in archive to install or not to install
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type toolProps Tool
+
+// FindToolfile searches Toolfile in dir and returns its path
+func FindToolfile(dir string) (path string, err error) {
+	for _, name := range ToolfileNames {
+		path = filepath.Join(dir, name)
+		if _, _err := os.Stat(path); _err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("Toolfile is not found in %s. Candidates: %v", dir, ToolfileNames)
+}
+
+// LoadToolfile reads and decodes Toolfile on given path
+func LoadToolfile(path string) (tf *Toolfile, err error) {
+	raw, _err := ioutil.ReadFile(path)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Can't read Toolfile: %s", path)
+	}
+	tf, _err = DecodeToolfileJSON(raw)
+	if _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to decode Toolfile: %s", path)
+	}
+	tf.path = path
+	return tf, nil
+}
+
+func DecodeToolfileJSON(b []byte) (tf *Toolfile, err error) {
+	var props toolfileProps
+	if _err := json.Unmarshal(b, &props); _err != nil {
+		return nil, erron.Errorwf(_err, "Failed to unmarshal JSON: %s", b)
+	}
+	for _, t := range props.Tools {
+		if t.Name == "" {
+			return nil, fmt.Errorf("Item without name is declared: %+v", t)
+		}
+	}
+	return &Toolfile{toolfileProps: &props}, nil
+}
+
+func (tf *Toolfile) String() string {
+	return fmt.Sprintf("%+v", *tf.toolfileProps)
+}
+
+// Path returns the file path where tf is loaded from
+func (tf *Toolfile) Path() (path string) {
+	return tf.path
+}
+
+// BaseDir returns the directory from which relative paths in tf are resolved
+func (tf *Toolfile) BaseDir() (dir string) {
+	if tf.path == "" {
+		return "."
+	}
+	return filepath.Dir(tf.path)
+}
+
+// GetServer returns index server URL for the tool. Empty string means the default server
+func (tf *Toolfile) GetServer(t Tool) (server string) {
+	if t.Server != "" {
+		return t.Server
+	}
+	return tf.Server
+}
+
+// GetDir returns absolute path of the directory where the tool should be installed
+func (tf *Toolfile) GetDir(t Tool) (dir string, err error) {
+	dir = t.Dir
+	if dir == "" {
+		dir = tf.Dir
+	}
+	if dir == "" {
+		dir = DefaultToolDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(tf.BaseDir(), dir)
+	}
+	abs, _err := filepath.Abs(dir)
+	if _err != nil {
+		return "", erron.Errorwf(_err, "Failed to get absolute path: %s", dir)
+	}
+	return abs, nil
+}
+
+// Source returns the argument for installation like "NAME[@VERSION]"
+func (t Tool) Source() (src string) {
+	if t.Version == "" {
+		return t.Name
+	}
+	return fmt.Sprintf("%s@%s", t.Name, t.Version)
+}
+
+func (t *Tool) UnmarshalJSON(b []byte) (err error) {
+	var spec string
+	if _err := json.Unmarshal(b, &spec); _err == nil {
+		if reToolSpec.MatchString(spec) {
+			matched := reToolSpec.FindStringSubmatch(spec)
+			*t = Tool{Name: matched[1], Version: matched[2]}
+		} else {
+			*t = Tool{Name: spec}
+		}
+		return nil
+	}
+
+	var props toolProps
+	if _err := json.Unmarshal(b, &props); _err != nil {
+		return _err
+	}
+	*t = Tool(props)
+	return nil
+}